@@ -0,0 +1,178 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const applyInput = `{
+	"name": "Perceval",
+	"createdAt": "2021-01-01T10:00:00Z",
+	"titles": ["Knight", "Le Gaulois"],
+	"manager": {"name": "Arthur", "titles": ["King"]},
+	"quests": [{"id": 1, "done": false}, {"id": 2, "done": true}]
+}`
+
+func decodeApplyInput(t *testing.T) interface{} {
+	t.Helper()
+	var document interface{}
+	if err := json.Unmarshal([]byte(applyInput), &document); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return document
+}
+
+func TestApplyDoesNotMutateInput(t *testing.T) {
+	tests := map[string]struct {
+		modifications  []JSONModification
+		expectedOutput string
+	}{
+		"set and remove": {
+			modifications:  []JSONModification{Set("manager.titles[1]", "Suzerain"), Remove("titles[0]"), Remove("quests[1].done"), Set("quests[0].id", 3)},
+			expectedOutput: `{"name": "Perceval", "createdAt": "2021-01-01T10:00:00Z", "titles": ["Le Gaulois"], "manager": {"name": "Arthur", "titles": ["King", "Suzerain"]}, "quests": [{"id": 3, "done": false}, {"id": 2}]}`,
+		},
+		"merge": {
+			modifications: []JSONModification{
+				Merge("manager", map[string]interface{}{"titles": []interface{}{"Suzerain"}, "age": 40}, MergeOptions{Arrays: ArrayConcat}),
+				Merge("quests", []interface{}{map[string]interface{}{"id": 2, "done": false}}, MergeOptions{Arrays: ArrayMergeByKey, ArrayKey: "id"}),
+				Merge("titles", []interface{}{"Provençal"}, MergeOptions{Arrays: ArrayMergeByIndex}),
+			},
+			expectedOutput: `{"name": "Perceval", "createdAt": "2021-01-01T10:00:00Z", "titles": ["Provençal", "Le Gaulois"], "manager": {"name": "Arthur", "titles": ["King", "Suzerain"], "age": 40}, "quests": [{"id": 1, "done": false}, {"id": 2, "done": false}]}`,
+		},
+		"iteration and scopes": {
+			modifications: []JSONModification{
+				ForEach("quests", Set("done", true)),
+				Scoped("manager", Remove("titles[0]")),
+				Scoped("quests[*]", Set("reward", 10)),
+			},
+			expectedOutput: `{"name": "Perceval", "createdAt": "2021-01-01T10:00:00Z", "titles": ["Knight", "Le Gaulois"], "manager": {"name": "Arthur", "titles": []}, "quests": [{"id": 1, "done": true, "reward": 10}, {"id": 2, "done": true, "reward": 10}]}`,
+		},
+		"time and conditions": {
+			modifications: []JSONModification{
+				ShiftTime("createdAt", time.Hour),
+				When(Equals("name", "Perceval"), Set("manager.name", "Léodagan")),
+			},
+			expectedOutput: `{"name": "Perceval", "createdAt": "2021-01-01T11:00:00Z", "titles": ["Knight", "Le Gaulois"], "manager": {"name": "Léodagan", "titles": ["King"]}, "quests": [{"id": 1, "done": false}, {"id": 2, "done": true}]}`,
+		},
+		"diff changes": {
+			modifications: []JSONModification{
+				Change{Type: ChangeAdd, Path: "titles[1]", NewValue: "Provençal"}.ToModification(),
+				Change{Type: ChangeRemove, Path: "quests[0]"}.ToModification(),
+			},
+			expectedOutput: `{"name": "Perceval", "createdAt": "2021-01-01T10:00:00Z", "titles": ["Knight", "Provençal", "Le Gaulois"], "manager": {"name": "Arthur", "titles": ["King"]}, "quests": [{"id": 2, "done": true}]}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			document := decodeApplyInput(t)
+			output, err := Apply(document, test.modifications...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := DeepEqual(document, decodeApplyInput(t)); diff != "" {
+				t.Errorf("input was mutated: " + diff)
+			}
+			encoded, err := json.Marshal(output)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if message, ok := JSONEqual(string(encoded), test.expectedOutput); !ok {
+				t.Error("unexpected output: " + message)
+			}
+		})
+	}
+}
+
+func TestApplySharesUntouchedElements(t *testing.T) {
+	document := decodeApplyInput(t)
+	output, err := Apply(document, Set("manager.name", "Léodagan"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	original, modified := document.(map[string]interface{}), output.(map[string]interface{})
+	same := func(a, b interface{}) bool {
+		return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+	}
+	if same(original, modified) || same(original["manager"], modified["manager"]) {
+		t.Error("modified elements should be copies")
+	}
+	if !same(original["quests"], modified["quests"]) || !same(original["titles"], modified["titles"]) {
+		t.Error("untouched elements should be shared")
+	}
+	if !same(original["manager"].(map[string]interface{})["titles"], modified["manager"].(map[string]interface{})["titles"]) {
+		t.Error("untouched elements of modified objects should be shared")
+	}
+}
+
+func TestApplyDoesNotAliasArrays(t *testing.T) {
+	array := make([]interface{}, 3, 10)
+	array[0], array[1], array[2] = "Knight", "Le Gaulois", "Provençal"
+
+	removed, err := Apply(array, Remove("[0]"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first, err := Apply(array, Set("[3]", "first"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := Apply(array, Set("[3]", "second"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diff := DeepEqual(array, []interface{}{"Knight", "Le Gaulois", "Provençal"}); diff != "" {
+		t.Errorf("input was mutated: " + diff)
+	}
+	if diff := DeepEqual(removed, []interface{}{"Le Gaulois", "Provençal"}); diff != "" {
+		t.Errorf("unexpected output after removal: " + diff)
+	}
+	if diff := DeepEqual(first, []interface{}{"Knight", "Le Gaulois", "Provençal", "first"}); diff != "" {
+		t.Errorf("unexpected first output: " + diff)
+	}
+	if diff := DeepEqual(second, []interface{}{"Knight", "Le Gaulois", "Provençal", "second"}); diff != "" {
+		t.Errorf("unexpected second output: " + diff)
+	}
+}
+
+// TestApplyConcurrentUse is meant to be run with the race detector
+func TestApplyConcurrentUse(t *testing.T) {
+	document := decodeApplyInput(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value := "title " + strconv.Itoa(i)
+			for j := 0; j < 50; j++ {
+				output, err := Apply(document,
+					Set("manager.titles[0]", value),
+					Remove("titles[1]"),
+					ForEach("quests", Set("owner", value)),
+					Merge("", map[string]interface{}{"name": value}, MergeOptions{}),
+				)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				modified := output.(map[string]interface{})
+				if modified["name"] != value || modified["manager"].(map[string]interface{})["titles"].([]interface{})[0] != value {
+					t.Errorf("goroutine %d got the modifications of another one: %v", i, modified)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if diff := DeepEqual(document, decodeApplyInput(t)); diff != "" {
+		t.Errorf("input was mutated: " + diff)
+	}
+}
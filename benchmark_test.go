@@ -0,0 +1,118 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// benchmarkDocuments are documents of various shapes, written as Modify writes them, along with a path to an element deep inside them
+var benchmarkDocuments = map[string]struct {
+	document string
+	path     string
+}{
+	"small": {
+		document: `{"manager":{"name":"Arthur","titles":["King"]},"name":"Perceval","questsAchieved":0}`,
+		path:     "manager.titles[0]",
+	},
+	"deep": {
+		document: strings.Repeat(`{"knight":[`, 64) + `"Perceval"` + strings.Repeat(`]}`, 64),
+		path:     strings.TrimPrefix(strings.Repeat(".knight[0]", 64), "."),
+	},
+	"wide": {
+		document: wideDocument(2000),
+		path:     "knights[1000].name",
+	},
+}
+
+func wideDocument(size int) string {
+	knights := make([]interface{}, 0, size)
+	for i := 0; i < size; i++ {
+		knights = append(knights, map[string]interface{}{
+			"id":     i,
+			"name":   "Knight " + strconv.Itoa(i),
+			"titles": []interface{}{"Knight", "of the Round Table"},
+		})
+	}
+	encoded, _ := json.Marshal(map[string]interface{}{"knights": knights})
+	return string(encoded)
+}
+
+func BenchmarkModify(b *testing.B) {
+	for name, test := range benchmarkDocuments {
+		test := test
+		b.Run(name+"/set", func(b *testing.B) {
+			benchmarkModify(b, test.document, Set(test.path, "Suzerain"))
+		})
+		b.Run(name+"/remove", func(b *testing.B) {
+			benchmarkModify(b, test.document, Remove(test.path))
+		})
+	}
+}
+
+func benchmarkModify(b *testing.B, document string, modification JSONModification) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(document)))
+	for i := 0; i < b.N; i++ {
+		if _, err := Modify(document, modification); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkModifyDecoding benchmarks Modify with modifications it cannot apply to the bytes of the documents
+func BenchmarkModifyDecoding(b *testing.B) {
+	for name, test := range benchmarkDocuments {
+		test := test
+		b.Run(name+"/set", func(b *testing.B) {
+			benchmarkModify(b, test.document, Chain(Set(test.path, "Suzerain")))
+		})
+		b.Run(name+"/remove", func(b *testing.B) {
+			benchmarkModify(b, test.document, Chain(Remove(test.path)))
+		})
+	}
+}
+
+func BenchmarkApply(b *testing.B) {
+	for name, test := range benchmarkDocuments {
+		var document interface{}
+		if err := json.Unmarshal([]byte(test.document), &document); err != nil {
+			b.Fatal(err)
+		}
+		modification := Set(test.path, "Suzerain")
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Apply(document, modification); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSet(b *testing.B) {
+	path := benchmarkDocuments["deep"].path
+	b.Run("string path", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Set(path, "Suzerain")
+		}
+	})
+	b.Run("uncached string path", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := parseJSONPath(path); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("compiled path", func(b *testing.B) {
+		compiled := MustCompilePath(path)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			SetPath(compiled, "Suzerain")
+		}
+	})
+}
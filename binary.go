@@ -0,0 +1,258 @@
+package slowjsonmutator
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// maxExactJSONInteger is the largest integer that JSON numbers, decoded as float64, hold exactly
+const maxExactJSONInteger = 1 << 53
+
+type binaryKind int
+
+const (
+	binaryScalar binaryKind = iota
+	binaryObject
+	binaryArray
+	binaryTag
+)
+
+// binaryNode is a value decoded from a binary format such as CBOR or MessagePack,
+// along with what is needed to encode it back
+type binaryNode struct {
+	kind binaryKind
+	// raw is the encoding of the value, written as is as long as the value is unchanged
+	raw []byte
+	// head is the first byte of the original encoding of a scalar, which tells its type and width
+	head    byte
+	hasHead bool
+	// value is the JSON value of a scalar
+	value interface{}
+	// entries are the members of an object or the elements of an array
+	entries []*binaryEntry
+	// tag is the number of a tag, and content the value it annotates
+	tag     uint64
+	content *binaryNode
+	// original is the value returned by toValue, which the elements of modified arrays are matched with
+	original interface{}
+}
+
+// binaryEntry is a member of an object or an element of an array
+type binaryEntry struct {
+	key    string
+	rawKey []byte
+	node   *binaryNode
+}
+
+// binaryFormat reads and writes the values of a binary format
+type binaryFormat interface {
+	// parse decodes the single value encoded by raw
+	parse(raw []byte) (*binaryNode, error)
+	// appendHead appends the start of the encoding of an object or array of count entries, or of a tag
+	appendHead(buffer *bytes.Buffer, node *binaryNode, count int) error
+	// appendScalar appends the encoding of a scalar, keeping the type and width of its original encoding when possible
+	appendScalar(buffer *bytes.Buffer, node *binaryNode) error
+}
+
+// modifyBinary applies modifications to data encoded in format, converted to JSON data and back
+func modifyBinary(format binaryFormat, input []byte, modifications []JSONModification) ([]byte, error) {
+	root, err := format.parse(input)
+	if err != nil {
+		return nil, err
+	}
+	modified, err := Apply(root.toValue(), modifications...)
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	if err := encodeBinaryNode(format, &output, reconcileBinaryNode(root, modified)); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
+
+// toValue converts the node to untyped JSON data, and keeps it as the original value of the node
+func (n *binaryNode) toValue() interface{} {
+	switch n.kind {
+	case binaryObject:
+		object := make(map[string]interface{}, len(n.entries))
+		for _, entry := range n.entries {
+			object[entry.key] = entry.node.toValue()
+		}
+		n.original = object
+	case binaryArray:
+		array := make([]interface{}, 0, len(n.entries))
+		for _, entry := range n.entries {
+			array = append(array, entry.node.toValue())
+		}
+		n.original = array
+	case binaryTag:
+		n.original = n.content.toValue()
+	default:
+		n.original = n.value
+	}
+	return n.original
+}
+
+// reconcileBinaryNode returns a node holding value, keeping the original encoding of node where possible
+func reconcileBinaryNode(node *binaryNode, value interface{}) *binaryNode {
+	if node == nil {
+		return newBinaryNode(value)
+	}
+
+	switch node.kind {
+	case binaryTag:
+		content := reconcileBinaryNode(node.content, value)
+		if content == node.content {
+			return node
+		}
+		return &binaryNode{kind: binaryTag, tag: node.tag, content: content}
+	case binaryObject:
+		if value, ok := value.(map[string]interface{}); ok {
+			keys := make([]string, len(node.entries))
+			for i, entry := range node.entries {
+				keys[i] = entry.key
+			}
+			states, added := planMembers(keys, value)
+			unchanged := membersUnchanged(states, added)
+			reconciled := &binaryNode{kind: binaryObject, entries: make([]*binaryEntry, 0, len(value))}
+			for i, entry := range node.entries {
+				switch states[i] {
+				case memberShadowed:
+					reconciled.entries = append(reconciled.entries, entry)
+				case memberHeld:
+					element := reconcileBinaryNode(entry.node, value[entry.key])
+					unchanged = unchanged && element == entry.node
+					reconciled.entries = append(reconciled.entries, &binaryEntry{key: entry.key, rawKey: entry.rawKey, node: element})
+				}
+			}
+			if unchanged {
+				return node
+			}
+			for _, attribute := range added {
+				reconciled.entries = append(reconciled.entries, &binaryEntry{key: attribute, node: newBinaryNode(value[attribute])})
+			}
+			return reconciled
+		}
+	case binaryArray:
+		if value, ok := value.([]interface{}); ok {
+			originals := make([]interface{}, len(node.entries))
+			for i, entry := range node.entries {
+				originals[i] = entry.node.original
+			}
+			matches := matchElements(originals, value)
+			unchanged := elementsUnchanged(matches, len(node.entries))
+			reconciled := &binaryNode{kind: binaryArray, entries: make([]*binaryEntry, 0, len(value))}
+			for i, element := range value {
+				var original *binaryNode
+				if matches[i] >= 0 {
+					original = node.entries[matches[i]].node
+				}
+				elementNode := reconcileBinaryNode(original, element)
+				unchanged = unchanged && elementNode == original
+				reconciled.entries = append(reconciled.entries, &binaryEntry{node: elementNode})
+			}
+			if unchanged {
+				return node
+			}
+			return reconciled
+		}
+	default:
+		if sameScalar(node.value, value) {
+			return node
+		}
+		if !isContainer(value) {
+			return &binaryNode{kind: binaryScalar, head: node.head, hasHead: node.hasHead, value: value}
+		}
+	}
+	return newBinaryNode(value)
+}
+
+// newBinaryNode returns a node holding value, with no original encoding
+func newBinaryNode(value interface{}) *binaryNode {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		node := &binaryNode{kind: binaryObject}
+		for _, attribute := range sortedAttributes(value) {
+			node.entries = append(node.entries, &binaryEntry{key: attribute, node: newBinaryNode(value[attribute])})
+		}
+		return node
+	case []interface{}:
+		node := &binaryNode{kind: binaryArray}
+		for _, element := range value {
+			node.entries = append(node.entries, &binaryEntry{node: newBinaryNode(element)})
+		}
+		return node
+	default:
+		return &binaryNode{kind: binaryScalar, value: value}
+	}
+}
+
+func encodeBinaryNode(format binaryFormat, buffer *bytes.Buffer, node *binaryNode) error {
+	if node.raw != nil {
+		buffer.Write(node.raw)
+		return nil
+	}
+	switch node.kind {
+	case binaryScalar:
+		return format.appendScalar(buffer, node)
+	case binaryTag:
+		if err := format.appendHead(buffer, node, 0); err != nil {
+			return err
+		}
+		return encodeBinaryNode(format, buffer, node.content)
+	}
+
+	if err := format.appendHead(buffer, node, len(node.entries)); err != nil {
+		return err
+	}
+	for _, entry := range node.entries {
+		if node.kind == binaryObject {
+			if entry.rawKey != nil {
+				buffer.Write(entry.rawKey)
+			} else if err := format.appendScalar(buffer, &binaryNode{value: entry.key}); err != nil {
+				return err
+			}
+		}
+		if err := encodeBinaryNode(format, buffer, entry.node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exactJSONInteger converts an integer to a JSON number, if it holds it exactly
+func exactJSONInteger(integer interface{}) (float64, error) {
+	var number float64
+	switch integer := integer.(type) {
+	case int64:
+		number = float64(integer)
+	case uint64:
+		number = float64(integer)
+	default:
+		number = reflect.ValueOf(integer).Convert(reflect.TypeOf(number)).Float()
+	}
+	if math.Abs(number) > maxExactJSONInteger {
+		return 0, fmt.Errorf("cannot represent integer [%v] exactly as a JSON number", integer)
+	}
+	return number, nil
+}
+
+// jsonFloat checks that a float can be a JSON number
+func jsonFloat(number float64) (float64, error) {
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("cannot represent [%v] as a JSON number", number)
+	}
+	return number, nil
+}
+
+// integralValue returns the integer held by a JSON number, if it is one that JSON holds exactly
+func integralValue(value interface{}) (int64, bool) {
+	number, ok := value.(float64)
+	if !ok || number != math.Trunc(number) || math.Abs(number) > maxExactJSONInteger {
+		return 0, false
+	}
+	return int64(number), true
+}
@@ -0,0 +1,224 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// unexpectedPropertyName is the name of the property added to objects that do not allow additional properties
+const unexpectedPropertyName = "unexpectedProperty"
+
+// NegativeCase is a named list of modifications that turns a valid document into an invalid one
+type NegativeCase struct {
+	// Name describes the case, for instance "manager: missing required property \"name\""
+	Name string
+	// Path is the path of the element the case is about
+	Path string
+	// Keyword is the schema keyword the case violates
+	Keyword string
+	// Modifications make the document invalid when applied to it
+	Modifications []JSONModification
+}
+
+// NegativeCases enumerates targeted ways of making document, which must conform to the schema, invalid:
+// one missing required property, one value just outside minimum or maximum, one string just outside
+// minLength or maxLength, one value outside enum and one extra property where additionalProperties is false.
+// Only cases that actually make the document invalid are returned, in a deterministic order.
+func (s *Schema) NegativeCases(document string) ([]NegativeCase, error) {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(document), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+	if violations := s.Validate(parsed); len(violations) != 0 {
+		return nil, fmt.Errorf("cannot derive negative cases from an invalid document: %w", &SchemaValidationError{Violations: violations})
+	}
+
+	candidates, err := s.negativeCases(s.root, parsed, "", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	cases := make([]NegativeCase, 0, len(candidates))
+	for _, candidate := range candidates {
+		mutated, err := Apply(parsed, candidate.Modifications...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply negative case %q: %w", candidate.Name, err)
+		}
+		if len(s.Validate(mutated)) != 0 {
+			cases = append(cases, candidate)
+		}
+	}
+	return cases, nil
+}
+
+func (s *Schema) negativeCases(schema interface{}, instance interface{}, path string, depth int) ([]NegativeCase, error) {
+	schemaObject, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	schemaObject, err := (&generator{schema: s}).flatten(schemaObject, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []NegativeCase
+	if enum, ok := schemaObject["enum"].([]interface{}); ok && path != "" {
+		cases = append(cases, newNegativeCase(path, "enum", "value outside enum", Set(path, valueOutsideEnum(enum, instance))))
+	}
+
+	switch instance := instance.(type) {
+	case float64:
+		cases = append(cases, numberNegativeCases(schemaObject, path)...)
+	case string:
+		cases = append(cases, stringNegativeCases(schemaObject, path)...)
+	case []interface{}:
+		prefixItems, _ := schemaObject["prefixItems"].([]interface{})
+		items, hasItems := schemaObject["items"]
+		for i, element := range instance {
+			var itemSchema interface{}
+			if i < len(prefixItems) {
+				itemSchema = prefixItems[i]
+			} else if hasItems {
+				itemSchema = items
+			}
+			itemCases, err := s.negativeCases(itemSchema, element, path+"["+strconv.Itoa(i)+"]", depth+1)
+			if err != nil {
+				return nil, err
+			}
+			cases = append(cases, itemCases...)
+		}
+	case map[string]interface{}:
+		objectCases, err := s.objectNegativeCases(schemaObject, instance, path, depth)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, objectCases...)
+	}
+	return cases, nil
+}
+
+func (s *Schema) objectNegativeCases(schema map[string]interface{}, instance map[string]interface{}, path string, depth int) ([]NegativeCase, error) {
+	var cases []NegativeCase
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, attribute := range required {
+			attribute, ok := attribute.(string)
+			if !ok || !addressableAttributeRegexp.MatchString(attribute) {
+				continue
+			}
+			if _, present := instance[attribute]; present {
+				cases = append(cases, newNegativeCase(path, "required", fmt.Sprintf("missing required property %q", attribute), Remove(joinPath(path, attribute))))
+			}
+		}
+	}
+	if additionalProperties, ok := schema["additionalProperties"]; ok && additionalProperties == false {
+		cases = append(cases, newNegativeCase(path, "additionalProperties", fmt.Sprintf("additional property %q", unexpectedPropertyName), Set(joinPath(path, unexpectedPropertyName), "unexpected")))
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	for _, attribute := range sortedAttributes(instance) {
+		propertySchema, ok := properties[attribute]
+		if !ok || !addressableAttributeRegexp.MatchString(attribute) {
+			continue
+		}
+		propertyCases, err := s.negativeCases(propertySchema, instance[attribute], joinPath(path, attribute), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, propertyCases...)
+	}
+	return cases, nil
+}
+
+func numberNegativeCases(schema map[string]interface{}, path string) []NegativeCase {
+	if path == "" {
+		return nil
+	}
+	step := func(bound, direction float64) float64 {
+		if schema["type"] == "integer" {
+			return bound + direction
+		}
+		return math.Nextafter(bound, math.Inf(int(direction)))
+	}
+
+	var cases []NegativeCase
+	if minimum, ok := schema["minimum"].(float64); ok {
+		cases = append(cases, newNegativeCase(path, "minimum", "value just below minimum", Set(path, step(minimum, -1))))
+	}
+	if minimum, ok := schema["exclusiveMinimum"].(float64); ok {
+		cases = append(cases, newNegativeCase(path, "exclusiveMinimum", "value equal to exclusiveMinimum", Set(path, minimum)))
+	}
+	if maximum, ok := schema["maximum"].(float64); ok {
+		cases = append(cases, newNegativeCase(path, "maximum", "value just above maximum", Set(path, step(maximum, 1))))
+	}
+	if maximum, ok := schema["exclusiveMaximum"].(float64); ok {
+		cases = append(cases, newNegativeCase(path, "exclusiveMaximum", "value equal to exclusiveMaximum", Set(path, maximum)))
+	}
+	return cases
+}
+
+func stringNegativeCases(schema map[string]interface{}, path string) []NegativeCase {
+	if path == "" {
+		return nil
+	}
+	var cases []NegativeCase
+	if minLength, ok := schema["minLength"].(float64); ok && minLength >= 1 {
+		cases = append(cases, newNegativeCase(path, "minLength", "string just shorter than minLength", Set(path, strings.Repeat("a", int(minLength)-1))))
+	}
+	if maxLength, ok := schema["maxLength"].(float64); ok {
+		cases = append(cases, newNegativeCase(path, "maxLength", "string just longer than maxLength", Set(path, strings.Repeat("a", int(maxLength)+1))))
+	}
+	return cases
+}
+
+// valueOutsideEnum returns a value of the same type as current, that is not in enum
+func valueOutsideEnum(enum []interface{}, current interface{}) interface{} {
+	contains := func(candidate interface{}) bool {
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, candidate) {
+				return true
+			}
+		}
+		return false
+	}
+	switch current := current.(type) {
+	case float64:
+		candidate := current
+		for contains(candidate) {
+			candidate++
+		}
+		return candidate
+	case bool:
+		if !contains(!current) {
+			return !current
+		}
+	}
+	candidate := fmt.Sprintf("not %v", current)
+	for contains(candidate) {
+		candidate = "not " + candidate
+	}
+	return candidate
+}
+
+func newNegativeCase(path, keyword, description string, modifications ...JSONModification) NegativeCase {
+	displayedPath := path
+	if displayedPath == "" {
+		displayedPath = "(root)"
+	}
+	return NegativeCase{
+		Name:          displayedPath + ": " + description,
+		Path:          path,
+		Keyword:       keyword,
+		Modifications: modifications,
+	}
+}
+
+func joinPath(path, attribute string) string {
+	if path == "" {
+		return attribute
+	}
+	return path + "." + attribute
+}
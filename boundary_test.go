@@ -0,0 +1,132 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNegativeCases(t *testing.T) {
+	schema, err := ParseSchema(knightSchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	document := `{"name": "Perceval", "rank": "knight", "questsAchieved": 3, "titles": ["Le Gaulois"], "manager": {"name": "Arthur"}}`
+
+	cases, err := schema.NegativeCases(document)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedNames := []string{
+		`(root): missing required property "name"`,
+		`(root): missing required property "rank"`,
+		`(root): additional property "unexpectedProperty"`,
+		`manager: missing required property "name"`,
+		`name: string just shorter than minLength`,
+		`name: string just longer than maxLength`,
+		`questsAchieved: value just below minimum`,
+		`questsAchieved: value just above maximum`,
+		`rank: value outside enum`,
+	}
+	names := make([]string, 0, len(cases))
+	for _, negativeCase := range cases {
+		names = append(names, negativeCase.Name)
+	}
+	if diff := DeepEqual(names, expectedNames); diff != "" {
+		t.Errorf("unexpected cases: " + diff)
+	}
+
+	for _, negativeCase := range cases {
+		t.Run(negativeCase.Name, func(t *testing.T) {
+			mutated, err := Modify(document, negativeCase.Modifications...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			violations, err := Validate(knightSchema, mutated)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(violations) != 1 || violations[0].Keyword != negativeCase.Keyword {
+				t.Errorf("expected a single %v violation, got %v", negativeCase.Keyword, violations)
+			}
+		})
+	}
+}
+
+func TestNegativeCasesBoundaries(t *testing.T) {
+	tests := map[string]struct {
+		schema         string
+		document       string
+		expectedOutput []string
+	}{
+		"number bounds": {
+			schema:         `{"properties": {"ratio": {"type": "number", "exclusiveMinimum": 0, "maximum": 1}}}`,
+			document:       `{"ratio": 0.5}`,
+			expectedOutput: []string{`{"ratio":0}`, `{"ratio":1.0000000000000002}`},
+		},
+		"enum of numbers": {
+			schema:         `{"items": {"enum": [1, 2, 3]}}`,
+			document:       `[2]`,
+			expectedOutput: []string{`[4]`},
+		},
+		"enum that is not violated by its negation": {
+			schema:         `{"properties": {"knight": {"enum": [true, false, "not true"]}}}`,
+			document:       `{"knight": true}`,
+			expectedOutput: []string{`{"knight":"not not true"}`},
+		},
+		"cases hidden by other constraints are dropped": {
+			schema:   `{"anyOf": [{"required": ["name"]}, {"required": ["title"]}]}`,
+			document: `{"name": "Perceval", "title": "Knight"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			schema, err := ParseSchema(test.schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cases, err := schema.NegativeCases(test.document)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var outputs []string
+			for _, negativeCase := range cases {
+				outputs = append(outputs, ModifyOrPanic(test.document, negativeCase.Modifications...))
+			}
+			if diff := DeepEqual(outputs, test.expectedOutput); diff != "" {
+				t.Errorf("unexpected outputs: " + diff)
+			}
+		})
+	}
+}
+
+func TestNegativeCasesErrors(t *testing.T) {
+	schema, err := ParseSchema(knightSchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := map[string]struct {
+		document      string
+		expectedError error
+	}{
+		"invalid json": {
+			document:      `{`,
+			expectedError: errors.New("failed to parse document: unexpected end of JSON input"),
+		},
+		"document that does not conform": {
+			document: `{"name": "Perceval"}`,
+			expectedError: errors.New(`cannot derive negative cases from an invalid document: document does not conform to schema:
+- (root): missing required property "rank"`),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := schema.NegativeCases(test.document)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package slowjsonmutator
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity above which buffers are not kept for reuse, so that a single huge document does not pin memory
+const maxPooledBufferSize = 1 << 20
+
+var buffers = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer returns an empty buffer, to be given back with putBuffer once its content is no longer used
+func getBuffer() *bytes.Buffer {
+	return buffers.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buffer *bytes.Buffer) {
+	if buffer.Cap() > maxPooledBufferSize {
+		return
+	}
+	buffer.Reset()
+	buffers.Put(buffer)
+}
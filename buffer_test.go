@@ -0,0 +1,39 @@
+package slowjsonmutator
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPutBuffer(t *testing.T) {
+	tests := map[string]struct {
+		size         int
+		expectReused bool
+	}{
+		"small buffer": {
+			size:         1024,
+			expectReused: true,
+		},
+		"huge buffer": {
+			size: 2 * maxPooledBufferSize,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buffer := new(bytes.Buffer)
+			buffer.Grow(test.size)
+			buffer.WriteString("Perceval")
+			putBuffer(buffer)
+
+			// the pool may drop buffers at any time, so only the absence of reuse is certain
+			reused := getBuffer()
+			if reused == buffer && !test.expectReused {
+				t.Error("huge buffer should not have been pooled")
+			}
+			if reused.Len() != 0 {
+				t.Errorf("pooled buffer should be empty, got [%v]", reused.String())
+			}
+		})
+	}
+}
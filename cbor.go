@@ -0,0 +1,256 @@
+package slowjsonmutator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CBOR major types
+const (
+	cborUnsignedInteger = 0
+	cborNegativeInteger = 1
+	cborByteString      = 2
+	cborTextString      = 3
+	cborArray           = 4
+	cborMap             = 5
+	cborTag             = 6
+	cborSimple          = 7
+)
+
+// CBOR tags of big integers, which JSON numbers cannot hold exactly
+const (
+	cborPositiveBignum = 2
+	cborNegativeBignum = 3
+)
+
+// CBOR additional information values
+const (
+	cborOneByteArgument = 24
+	cborIndefinite      = 31
+	cborBreak           = 0xff
+	cborUndefined       = cborSimple<<5 | 23
+	cborHalfFloat       = cborSimple<<5 | 25
+	cborSingleFloat     = cborSimple<<5 | 26
+	cborDoubleFloat     = cborSimple<<5 | 27
+)
+
+var cborShortestFloatMode, _ = cbor.EncOptions{ShortestFloat: cbor.ShortestFloat16}.EncMode()
+
+// ModifyCBOR applies modifications to a CBOR data item, using the same paths as with JSON.
+// The item is converted to JSON data, modified, and converted back to CBOR.
+// What modifications leave untouched is written back byte for byte, and key order is kept;
+// new attributes are appended, sorted by name, to the maps that receive them.
+// Modified values keep the type and width of the value they replace when they fit:
+// integers stay integers of the same width, single and half precision floats stay so when exact,
+// and tags keep annotating their modified content. Byte strings are base64 strings in JSON,
+// and a string set in place of a byte string must be base64 too.
+// Maps with keys that are not text strings, integers that JSON numbers cannot hold exactly,
+// bignums, and values that JSON has no equivalent for, such as undefined or NaN, are rejected.
+func ModifyCBOR(input []byte, modifications ...JSONModification) ([]byte, error) {
+	return modifyBinary(cborFormat{}, input, modifications)
+}
+
+type cborFormat struct{}
+
+func (f cborFormat) parse(raw []byte) (*binaryNode, error) {
+	rest, err := cbor.UnmarshalFirst(raw, &cbor.RawMessage{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CBOR: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("unexpected data after CBOR data item")
+	}
+	return f.parseItem(raw)
+}
+
+// parseItem decodes a well-formed CBOR data item
+func (f cborFormat) parseItem(raw []byte) (*binaryNode, error) {
+	major, argument, headLength := cborHead(raw)
+	node := &binaryNode{raw: raw, head: raw[0], hasHead: true}
+	switch major {
+	case cborArray, cborMap:
+		node.kind = binaryArray
+		itemCount := int(argument)
+		if major == cborMap {
+			node.kind = binaryObject
+			itemCount *= 2
+		}
+		items, err := cborItems(raw[headLength:], itemCount, raw[0]&0x1f == cborIndefinite)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(items); i++ {
+			entry := &binaryEntry{}
+			if major == cborMap {
+				if items[i][0]>>5 != cborTextString {
+					return nil, fmt.Errorf("cannot use CBOR map key of major type %d as a JSON attribute", items[i][0]>>5)
+				}
+				if err := cbor.Unmarshal(items[i], &entry.key); err != nil {
+					return nil, err
+				}
+				entry.rawKey = items[i]
+				i++
+			}
+			if entry.node, err = f.parseItem(items[i]); err != nil {
+				return nil, err
+			}
+			node.entries = append(node.entries, entry)
+		}
+	case cborTag:
+		if argument == cborPositiveBignum || argument == cborNegativeBignum {
+			return nil, errors.New("cannot represent CBOR bignum exactly as a JSON number")
+		}
+		content, err := f.parseItem(raw[headLength:])
+		if err != nil {
+			return nil, err
+		}
+		node.kind, node.tag, node.content = binaryTag, argument, content
+	default:
+		if raw[0] == cborUndefined {
+			return nil, errors.New("cannot represent CBOR undefined as JSON")
+		}
+		var value interface{}
+		if err := cbor.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		var err error
+		if node.value, err = cborJSONValue(value); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// cborHead returns the major type and the argument of a well-formed CBOR data item, and the length of its head
+func cborHead(raw []byte) (byte, uint64, int) {
+	major, information := raw[0]>>5, raw[0]&0x1f
+	switch {
+	case information < cborOneByteArgument:
+		return major, uint64(information), 1
+	case information == cborOneByteArgument:
+		return major, uint64(raw[1]), 2
+	case information == cborOneByteArgument+1:
+		return major, uint64(binary.BigEndian.Uint16(raw[1:])), 3
+	case information == cborOneByteArgument+2:
+		return major, uint64(binary.BigEndian.Uint32(raw[1:])), 5
+	case information == cborOneByteArgument+3:
+		return major, binary.BigEndian.Uint64(raw[1:]), 9
+	default:
+		return major, 0, 1
+	}
+}
+
+// cborItems splits the content of a well-formed array or map into its data items
+func cborItems(data []byte, count int, indefinite bool) ([][]byte, error) {
+	var items [][]byte
+	for indefinite && data[0] != cborBreak || !indefinite && len(items) < count {
+		rest, err := cbor.UnmarshalFirst(data, &cbor.RawMessage{})
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, data[:len(data)-len(rest)])
+		data = rest
+	}
+	return items, nil
+}
+
+// cborJSONValue converts what the cbor package decodes into an interface{} to a JSON value
+func cborJSONValue(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil, bool, string:
+		return value, nil
+	case uint64, int64:
+		return exactJSONInteger(value)
+	case float64:
+		return jsonFloat(value)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(value), nil
+	default:
+		return nil, fmt.Errorf("cannot represent CBOR value [%v] of type %T as JSON", value, value)
+	}
+}
+
+func (cborFormat) appendHead(buffer *bytes.Buffer, node *binaryNode, count int) error {
+	switch node.kind {
+	case binaryObject:
+		buffer.Write(appendCBORHead(nil, cborMap, uint64(count), 0))
+	case binaryArray:
+		buffer.Write(appendCBORHead(nil, cborArray, uint64(count), 0))
+	default:
+		buffer.Write(appendCBORHead(nil, cborTag, node.tag, 0))
+	}
+	return nil
+}
+
+func (cborFormat) appendScalar(buffer *bytes.Buffer, node *binaryNode) error {
+	major, information := node.head>>5, node.head&0x1f
+	wasFloat := node.hasHead && cborHalfFloat <= node.head && node.head <= cborDoubleFloat
+	if integer, ok := integralValue(node.value); ok && !wasFloat {
+		if !node.hasHead || major > cborNegativeInteger || information > cborOneByteArgument+3 {
+			information = 0
+		}
+		if integer >= 0 {
+			buffer.Write(appendCBORHead(nil, cborUnsignedInteger, uint64(integer), information))
+		} else {
+			buffer.Write(appendCBORHead(nil, cborNegativeInteger, uint64(-1-integer), information))
+		}
+		return nil
+	}
+
+	var encoded []byte
+	var err error
+	switch value := node.value.(type) {
+	case float64:
+		switch {
+		case node.hasHead && node.head == cborHalfFloat:
+			encoded, err = cborShortestFloatMode.Marshal(value)
+		case node.hasHead && node.head == cborSingleFloat && float64(float32(value)) == value:
+			encoded, err = cbor.Marshal(float32(value))
+		default:
+			encoded, err = cbor.Marshal(value)
+		}
+	case string:
+		if node.hasHead && major == cborByteString {
+			decoded, decodeErr := base64.StdEncoding.DecodeString(value)
+			if decodeErr != nil {
+				return fmt.Errorf("cannot write [%q] in place of a CBOR byte string, it is not base64: %w", value, decodeErr)
+			}
+			encoded, err = cbor.Marshal(decoded)
+		} else {
+			encoded, err = cbor.Marshal(value)
+		}
+	default:
+		encoded, err = cbor.Marshal(value)
+	}
+	buffer.Write(encoded)
+	return err
+}
+
+// appendCBORHead appends the head of a data item, with an argument written on at least as many bytes as minimumInformation asks for
+func appendCBORHead(data []byte, major byte, argument uint64, minimumInformation byte) []byte {
+	major <<= 5
+	switch {
+	case argument < cborOneByteArgument && minimumInformation < cborOneByteArgument:
+		return append(data, major|byte(argument))
+	case argument <= math.MaxUint8 && minimumInformation <= cborOneByteArgument:
+		return append(data, major|cborOneByteArgument, byte(argument))
+	case argument <= math.MaxUint16 && minimumInformation <= cborOneByteArgument+1:
+		data = append(data, major|(cborOneByteArgument+1), 0, 0)
+		binary.BigEndian.PutUint16(data[len(data)-2:], uint16(argument))
+		return data
+	case argument <= math.MaxUint32 && minimumInformation <= cborOneByteArgument+2:
+		data = append(data, major|(cborOneByteArgument+2), 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(data[len(data)-4:], uint32(argument))
+		return data
+	default:
+		data = append(data, major|(cborOneByteArgument+3), 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(data[len(data)-8:], argument)
+		return data
+	}
+}
@@ -0,0 +1,95 @@
+package slowjsonmutator
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestModifyCBOR(t *testing.T) {
+	// {"name": "Perceval", "id": 1 on two bytes, "avatar": h'0102', "born": 1(1000), "score": 1.5 in single precision}
+	knight := "a5" + "646e616d65" + "68506572636576616c" + "626964" + "190001" + "66617661746172" + "420102" +
+		"64626f726e" + "c11903e8" + "6573636f7265" + "fa3fc00000"
+
+	tests := map[string]struct {
+		input          string
+		modifications  []JSONModification
+		expectedOutput string
+		expectedError  error
+	}{
+		"types and widths are kept": {
+			input:         knight,
+			modifications: []JSONModification{Set("name", "Karadoc"), Set("id", 2), Set("avatar", "AwQ="), Set("born", 2000), Set("score", 2.5), Set("title", "Knight")},
+			expectedOutput: "a6" + "646e616d65" + "674b617261646f63" + "626964" + "190002" + "66617661746172" + "420304" +
+				"64626f726e" + "c11907d0" + "6573636f7265" + "fa40200000" + "657469746c65" + "664b6e69676874",
+		},
+		"untouched values are kept byte for byte": {
+			input:          knight,
+			modifications:  []JSONModification{Remove("name")},
+			expectedOutput: "a4" + "626964" + "190001" + "66617661746172" + "420102" + "64626f726e" + "c11903e8" + "6573636f7265" + "fa3fc00000",
+		},
+		"elements after a removed one are kept byte for byte": {
+			// [1, h'6869', 1.5 in half precision]
+			input:          "83" + "01" + "426869" + "f93e00",
+			modifications:  []JSONModification{Remove("[0]")},
+			expectedOutput: "82" + "426869" + "f93e00",
+		},
+		"elements around a removed one are kept byte for byte": {
+			input:          "84" + "01" + "426869" + "f93e00" + "190001",
+			modifications:  []JSONModification{Remove("[1]")},
+			expectedOutput: "83" + "01" + "f93e00" + "190001",
+		},
+		"indefinite length array": {
+			input:          "9f0102ff",
+			modifications:  []JSONModification{Set("[2]", 3)},
+			expectedOutput: "83010203",
+		},
+		"string in place of a byte string": {
+			input:         knight,
+			modifications: []JSONModification{Set("avatar", "Perceval!")},
+			expectedError: errors.New(`cannot write ["Perceval!"] in place of a CBOR byte string, it is not base64: illegal base64 data at input byte 8`),
+		},
+		"map key that is not a string": {
+			input:         "a1016161",
+			expectedError: errors.New("cannot use CBOR map key of major type 0 as a JSON attribute"),
+		},
+		"integer too large for JSON": {
+			input:         "1b1000000000000000",
+			expectedError: errors.New("cannot represent integer [1152921504606846976] exactly as a JSON number"),
+		},
+		"NaN": {
+			input:         "f97e00",
+			expectedError: errors.New("cannot represent [NaN] as a JSON number"),
+		},
+		"undefined": {
+			input:         "a16161f7",
+			expectedError: errors.New("cannot represent CBOR undefined as JSON"),
+		},
+		"positive bignum": {
+			input:         "a16161c2420100",
+			expectedError: errors.New("cannot represent CBOR bignum exactly as a JSON number"),
+		},
+		"negative bignum": {
+			input:         "c34101",
+			expectedError: errors.New("cannot represent CBOR bignum exactly as a JSON number"),
+		},
+		"data after the data item": {
+			input:         "0102",
+			expectedError: errors.New("unexpected data after CBOR data item"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			input, _ := hex.DecodeString(test.input)
+			output, err := ModifyCBOR(input, test.modifications...)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if hex.EncodeToString(output) != test.expectedOutput {
+				t.Errorf("unexpected output: wanted [%v], got [%x]", test.expectedOutput, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+// Command sjm applies slowjsonmutator modifications to JSON documents from the command line.
+//
+// Usage:
+//
+//	sjm [-f FILE] [-i | -o FILE] [--json] OPERATION... [QUERY]
+//
+// Operations are applied in order, and can be repeated:
+//
+//	set PATH VALUE   sets the element at PATH to VALUE, which must be valid JSON
+//	remove PATH      removes the element at PATH
+//	patch FILE       applies the changes of FILE, as written by diff --json
+//
+// A query can end the list of operations, and is then printed instead of the document:
+//
+//	get PATH         prints the element at PATH
+//	diff FILE        prints the changes from the document to FILE
+//
+// For instance:
+//
+//	sjm set 'manager.titles[0].fr' '"Suzerain"' remove 'manager.name' -f in.json
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	sjm "github.com/remieven/slowjsonmutator-go"
+)
+
+const usage = `usage: sjm [-f FILE] [-i | -o FILE] [--json] OPERATION... [QUERY]
+
+Operations, applied in order:
+  set PATH VALUE   set the element at PATH to VALUE, which must be valid JSON
+  remove PATH      remove the element at PATH
+  patch FILE       apply the changes of FILE, as written by diff --json
+
+Queries, printed instead of the document:
+  get PATH         print the element at PATH
+  diff FILE        print the changes from the document to FILE
+
+Flags:
+  -f, --file FILE    read the document from FILE instead of stdin
+  -i, --in-place     write the document back to the file given with -f
+  -o, --output FILE  write the document to FILE instead of stdout
+      --json         print the changes of diff as JSON, to be used by patch
+  -h, --help         print this help
+`
+
+// operationArities gives the number of arguments of each operation and query
+var operationArities = map[string]int{
+	"set":    2,
+	"remove": 1,
+	"patch":  1,
+	"get":    1,
+	"diff":   1,
+}
+
+var errHelp = errors.New("help requested")
+
+type operation struct {
+	name      string
+	arguments []string
+}
+
+func (o operation) isQuery() bool {
+	return o.name == "get" || o.name == "diff"
+}
+
+type options struct {
+	file       string
+	inPlace    bool
+	output     string
+	jsonDiff   bool
+	operations []operation
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes sjm with the given arguments and returns its exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	opts, err := parseArguments(args)
+	if errors.Is(err, errHelp) {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "sjm: %v\n\n%s", err, usage)
+		return 2
+	}
+	if err := execute(opts, stdin, stdout); err != nil {
+		fmt.Fprintf(stderr, "sjm: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func parseArguments(args []string) (options, error) {
+	var opts options
+	for i := 0; i < len(args); i++ {
+		switch argument := args[i]; argument {
+		case "-h", "--help":
+			return options{}, errHelp
+		case "-i", "--in-place":
+			opts.inPlace = true
+		case "--json":
+			opts.jsonDiff = true
+		case "-f", "--file", "-o", "--output":
+			if i+1 == len(args) {
+				return options{}, fmt.Errorf("flag [%q] requires a file", argument)
+			}
+			i++
+			if argument == "-f" || argument == "--file" {
+				opts.file = args[i]
+			} else {
+				opts.output = args[i]
+			}
+		default:
+			arity, ok := operationArities[argument]
+			if !ok {
+				return options{}, fmt.Errorf("unknown operation [%q]", argument)
+			}
+			if len(args) <= i+arity {
+				return options{}, fmt.Errorf("operation [%q] requires %d argument(s)", argument, arity)
+			}
+			opts.operations = append(opts.operations, operation{name: argument, arguments: args[i+1 : i+1+arity]})
+			i += arity
+		}
+	}
+
+	if len(opts.operations) == 0 {
+		return options{}, errors.New("no operation given")
+	}
+	for _, operation := range opts.operations[:len(opts.operations)-1] {
+		if operation.isQuery() {
+			return options{}, fmt.Errorf("query [%q] must be the last operation", operation.name)
+		}
+	}
+	if opts.inPlace && (opts.file == "" || opts.file == "-") {
+		return options{}, errors.New("writing in place requires a file given with -f")
+	}
+	if opts.inPlace && opts.output != "" {
+		return options{}, errors.New("cannot both write in place and to an output file")
+	}
+	if opts.operations[len(opts.operations)-1].isQuery() && (opts.inPlace || opts.output != "") {
+		return options{}, errors.New("queries print their result and cannot be written to a file")
+	}
+	return opts, nil
+}
+
+func execute(opts options, stdin io.Reader, stdout io.Writer) error {
+	input, err := readInput(opts.file, stdin)
+	if err != nil {
+		return err
+	}
+
+	var modifications []sjm.JSONModification
+	var query *operation
+	for i := range opts.operations {
+		operation := opts.operations[i]
+		switch operation.name {
+		case "set":
+			path, err := sjm.CompilePath(operation.arguments[0])
+			if err != nil {
+				return err
+			}
+			var value interface{}
+			if err := json.Unmarshal([]byte(operation.arguments[1]), &value); err != nil {
+				return fmt.Errorf("invalid JSON value [%q] to set at path [%q]: %w", operation.arguments[1], operation.arguments[0], err)
+			}
+			modifications = append(modifications, sjm.SetPath(path, value))
+		case "remove":
+			path, err := sjm.CompilePath(operation.arguments[0])
+			if err != nil {
+				return err
+			}
+			modifications = append(modifications, sjm.RemovePath(path))
+		case "patch":
+			changes, err := readPatch(operation.arguments[0])
+			if err != nil {
+				return err
+			}
+			for _, change := range changes {
+				modifications = append(modifications, change.ToModification())
+			}
+		default:
+			query = &operation
+		}
+	}
+
+	output, err := sjm.Modify(input, modifications...)
+	if err != nil {
+		return err
+	}
+
+	if query != nil {
+		return executeQuery(*query, output, opts.jsonDiff, stdout)
+	}
+	switch {
+	case opts.inPlace:
+		return writeFile(opts.file, output)
+	case opts.output != "":
+		return writeFile(opts.output, output)
+	default:
+		_, err := fmt.Fprintln(stdout, output)
+		return err
+	}
+}
+
+func executeQuery(query operation, document string, jsonDiff bool, stdout io.Writer) error {
+	switch query.name {
+	case "get":
+		value, found, err := sjm.Get(document, query.arguments[0])
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("no element at path [%q]", query.arguments[0])
+		}
+		_, err = fmt.Fprintln(stdout, value)
+		return err
+	default:
+		other, err := os.ReadFile(query.arguments[0])
+		if err != nil {
+			return err
+		}
+		changes, err := sjm.Diff(document, string(other))
+		if err != nil {
+			return err
+		}
+		if jsonDiff {
+			if changes == nil {
+				changes = []sjm.Change{}
+			}
+			encoded, err := json.Marshal(changes)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(stdout, string(encoded))
+			return err
+		}
+		for _, change := range changes {
+			if _, err := fmt.Fprintln(stdout, change); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func readInput(file string, stdin io.Reader) (string, error) {
+	if file == "" || file == "-" {
+		input, err := io.ReadAll(stdin)
+		return string(input), err
+	}
+	input, err := os.ReadFile(file)
+	return string(input), err
+}
+
+func readPatch(file string) ([]sjm.Change, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var changes []sjm.Change
+	if err := json.Unmarshal(content, &changes); err != nil {
+		return nil, fmt.Errorf("failed to parse patch [%q]: %w", file, err)
+	}
+	for _, change := range changes {
+		switch change.Type {
+		case sjm.ChangeAdd, sjm.ChangeRemove, sjm.ChangeReplace:
+		default:
+			return nil, fmt.Errorf("failed to parse patch [%q]: unknown change type [%q]", file, change.Type)
+		}
+	}
+	return changes, nil
+}
+
+// writeFile writes document to file, keeping its permissions if it already exists
+func writeFile(file, document string) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(file); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.WriteFile(file, []byte(document+"\n"), mode)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	directory := t.TempDir()
+	files := map[string]string{
+		"knight.json": `{"name": "Perceval", "manager": {"name": "Arthur"}}`,
+		"other.json":  `{"name": "Karadoc", "manager": {"name": "Arthur"}, "title": "Knight"}`,
+		"patch.json":  `[{"type": "replace", "path": "name", "newValue": "Karadoc"}, {"type": "add", "path": "titles[0]", "newValue": "Knight"}]`,
+		"broken.json": `[{"type": "rename", "path": "name"}]`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	file := func(name string) string {
+		return filepath.Join(directory, name)
+	}
+
+	tests := map[string]struct {
+		args             []string
+		stdin            string
+		expectedCode     int
+		expectedStdout   string
+		expectedStderr   string
+		expectedFile     string
+		expectedFileName string
+	}{
+		"set from stdin": {
+			args:           []string{"set", "manager.titles[0].fr", `"Suzerain"`},
+			stdin:          `{"name": "Perceval"}`,
+			expectedStdout: `{"manager":{"titles":[{"fr":"Suzerain"}]},"name":"Perceval"}` + "\n",
+		},
+		"several operations on a file, with flags after operations": {
+			args:           []string{"set", "questsAchieved", "-1", "remove", "manager", "-f", file("knight.json")},
+			expectedStdout: `{"name":"Perceval","questsAchieved":-1}` + "\n",
+		},
+		"get": {
+			args:           []string{"-f", file("knight.json"), "set", "manager.age", "42", "get", "manager"},
+			expectedStdout: `{"age":42,"name":"Arthur"}` + "\n",
+		},
+		"get of a missing element": {
+			args:           []string{"-f", file("knight.json"), "get", "title"},
+			expectedCode:   1,
+			expectedStderr: "sjm: no element at path [\"title\"]\n",
+		},
+		"diff": {
+			args:           []string{"-f", file("knight.json"), "diff", file("other.json")},
+			expectedStdout: "~ name: \"Perceval\" -> \"Karadoc\"\n+ title: \"Knight\"\n",
+		},
+		"diff as json": {
+			args:           []string{"--json", "-f", file("knight.json"), "diff", file("knight.json")},
+			expectedStdout: "[]\n",
+		},
+		"patch": {
+			args:           []string{"-f", file("knight.json"), "patch", file("patch.json")},
+			expectedStdout: `{"manager":{"name":"Arthur"},"name":"Karadoc","titles":["Knight"]}` + "\n",
+		},
+		"patch with an unknown change type": {
+			args:           []string{"-f", file("knight.json"), "patch", file("broken.json")},
+			expectedCode:   1,
+			expectedStderr: "sjm: failed to parse patch [\"" + file("broken.json") + "\"]: unknown change type [\"rename\"]\n",
+		},
+		"output file": {
+			args:             []string{"-o", file("output.json"), "set", "name", `"Karadoc"`},
+			stdin:            `{}`,
+			expectedFileName: "output.json",
+			expectedFile:     `{"name":"Karadoc"}` + "\n",
+		},
+		"invalid value": {
+			args:           []string{"set", "name", "Karadoc"},
+			stdin:          `{}`,
+			expectedCode:   1,
+			expectedStderr: "sjm: invalid JSON value [\"Karadoc\"] to set at path [\"name\"]: invalid character 'K' looking for beginning of value\n",
+		},
+		"invalid path": {
+			args:           []string{"remove", "a..b"},
+			stdin:          `{}`,
+			expectedCode:   1,
+			expectedStderr: "sjm: cannot parse json path [\"a..b\"], it doesn't seem valid\n",
+		},
+		"help": {
+			args:           []string{"--help"},
+			expectedStdout: usage,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(test.args, strings.NewReader(test.stdin), &stdout, &stderr)
+			if code != test.expectedCode {
+				t.Errorf("unexpected exit code: wanted [%v], got [%v] (%s)", test.expectedCode, code, stderr.String())
+			}
+			if stdout.String() != test.expectedStdout {
+				t.Errorf("unexpected stdout: wanted [%v], got [%v]", test.expectedStdout, stdout.String())
+			}
+			if stderr.String() != test.expectedStderr {
+				t.Errorf("unexpected stderr: wanted [%v], got [%v]", test.expectedStderr, stderr.String())
+			}
+			if test.expectedFileName != "" {
+				content, err := os.ReadFile(file(test.expectedFileName))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if string(content) != test.expectedFile {
+					t.Errorf("unexpected file content: wanted [%v], got [%v]", test.expectedFile, string(content))
+				}
+			}
+		})
+	}
+}
+
+func TestRunInPlace(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "knight.json")
+	if err := os.WriteFile(file, []byte(`{"name": "Perceval"}`), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-i", "-f", file, "set", "title", `"Knight"`}, strings.NewReader(""), &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %v: %v", code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("unexpected stdout: %v", stdout.String())
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `{"name":"Perceval","title":"Knight"}` + "\n"; string(content) != expected {
+		t.Errorf("unexpected file content: wanted [%v], got [%v]", expected, string(content))
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("file permissions were not kept: got [%v]", info.Mode().Perm())
+	}
+}
+
+func TestParseArgumentsErrors(t *testing.T) {
+	tests := map[string]struct {
+		args          []string
+		expectedError string
+	}{
+		"no operation": {
+			args:          []string{"-f", "in.json"},
+			expectedError: "no operation given",
+		},
+		"unknown operation": {
+			args:          []string{"rename", "a", "b"},
+			expectedError: `unknown operation ["rename"]`,
+		},
+		"missing operation argument": {
+			args:          []string{"set", "name"},
+			expectedError: `operation ["set"] requires 2 argument(s)`,
+		},
+		"missing flag argument": {
+			args:          []string{"remove", "name", "-f"},
+			expectedError: `flag ["-f"] requires a file`,
+		},
+		"query before an operation": {
+			args:          []string{"get", "name", "remove", "name"},
+			expectedError: `query ["get"] must be the last operation`,
+		},
+		"in place without file": {
+			args:          []string{"-i", "remove", "name"},
+			expectedError: "writing in place requires a file given with -f",
+		},
+		"in place and output file": {
+			args:          []string{"-i", "-f", "in.json", "-o", "out.json", "remove", "name"},
+			expectedError: "cannot both write in place and to an output file",
+		},
+		"query written to a file": {
+			args:          []string{"-o", "out.json", "get", "name"},
+			expectedError: "queries print their result and cannot be written to a file",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseArguments(test.args)
+			if err == nil || err.Error() != test.expectedError {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+			}
+		})
+	}
+}
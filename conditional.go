@@ -0,0 +1,163 @@
+package slowjsonmutator
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// JSONPredicate is a function that checks a condition on parsed untyped json data
+type JSONPredicate func(interface{}) (bool, error)
+
+// JSONType is the type of a JSON value
+type JSONType string
+
+// JSON value types
+const (
+	JSONNull    JSONType = "null"
+	JSONBoolean JSONType = "boolean"
+	JSONNumber  JSONType = "number"
+	JSONString  JSONType = "string"
+	JSONArray   JSONType = "array"
+	JSONObject  JSONType = "object"
+)
+
+// When applies modifications only if predicate holds
+func When(predicate JSONPredicate, modifications ...JSONModification) JSONModification {
+	return func(toModify interface{}) (interface{}, error) {
+		holds, err := predicate(toModify)
+		if err != nil {
+			return nil, err
+		}
+		if !holds {
+			return toModify, nil
+		}
+		return applyModifications(toModify, modifications)
+	}
+}
+
+// Unless applies modifications only if predicate does not hold
+func Unless(predicate JSONPredicate, modifications ...JSONModification) JSONModification {
+	return When(Not(predicate), modifications...)
+}
+
+// Exists checks whether there is an element at the given path, even if it is null
+func Exists(path string) JSONPredicate {
+	return func(toCheck interface{}) (bool, error) {
+		pathSegments, err := parseJSONPath(path)
+		if err != nil {
+			return false, err
+		}
+		_, found := get(toCheck, pathSegments)
+		return found, nil
+	}
+}
+
+// Equals checks whether the element at the given path is equal to value once both are encoded to JSON
+func Equals(path string, value interface{}) JSONPredicate {
+	return func(toCheck interface{}) (bool, error) {
+		pathSegments, err := parseJSONPath(path)
+		if err != nil {
+			return false, err
+		}
+		current, found := get(toCheck, pathSegments)
+		if !found {
+			return false, nil
+		}
+		expected, err := normalizeValue(value)
+		if err != nil {
+			return false, fmt.Errorf("cannot compare value at path [%q]: %w", path, err)
+		}
+		actual, err := normalizeValue(current)
+		if err != nil {
+			return false, fmt.Errorf("cannot compare value at path [%q]: %w", path, err)
+		}
+		return reflect.DeepEqual(actual, expected), nil
+	}
+}
+
+// HasType checks whether the element at the given path exists and is of the given type
+func HasType(path string, jsonType JSONType) JSONPredicate {
+	return func(toCheck interface{}) (bool, error) {
+		pathSegments, err := parseJSONPath(path)
+		if err != nil {
+			return false, err
+		}
+		current, found := get(toCheck, pathSegments)
+		return found && jsonTypeOf(current) == jsonType, nil
+	}
+}
+
+// Matches checks whether the element at the given path is a string matching pattern
+func Matches(path string, pattern string) JSONPredicate {
+	compiledPattern, compileErr := regexp.Compile(pattern)
+	return func(toCheck interface{}) (bool, error) {
+		if compileErr != nil {
+			return false, fmt.Errorf("cannot compile pattern [%q]: %w", pattern, compileErr)
+		}
+		pathSegments, err := parseJSONPath(path)
+		if err != nil {
+			return false, err
+		}
+		current, found := get(toCheck, pathSegments)
+		if !found {
+			return false, nil
+		}
+		str, ok := current.(string)
+		return ok && compiledPattern.MatchString(str), nil
+	}
+}
+
+// Not negates predicate
+func Not(predicate JSONPredicate) JSONPredicate {
+	return func(toCheck interface{}) (bool, error) {
+		holds, err := predicate(toCheck)
+		return !holds && err == nil, err
+	}
+}
+
+// And checks whether all predicates hold; it stops at the first one that does not
+func And(predicates ...JSONPredicate) JSONPredicate {
+	return func(toCheck interface{}) (bool, error) {
+		for _, predicate := range predicates {
+			if holds, err := predicate(toCheck); err != nil || !holds {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+// Or checks whether at least one of predicates holds; it stops at the first one that does
+func Or(predicates ...JSONPredicate) JSONPredicate {
+	return func(toCheck interface{}) (bool, error) {
+		for _, predicate := range predicates {
+			if holds, err := predicate(toCheck); err != nil || holds {
+				return holds, err
+			}
+		}
+		return false, nil
+	}
+}
+
+func jsonTypeOf(value interface{}) JSONType {
+	if _, ok := toFloat64(value); ok {
+		return JSONNumber
+	}
+	switch value.(type) {
+	case nil:
+		return JSONNull
+	case bool:
+		return JSONBoolean
+	case string:
+		return JSONString
+	case []interface{}:
+		return JSONArray
+	case map[string]interface{}:
+		return JSONObject
+	}
+	if untyped, err := normalizeValue(value); err == nil {
+		return jsonTypeOf(untyped)
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConditionalModifications(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		modifications  []JSONModification
+		expectedOutput string
+		expectedError  error
+	}{
+		"apply modification when value equals": {
+			input: `{"tier": "gold"}`,
+			modifications: []JSONModification{
+				When(Equals("tier", "gold"), Set("discount", 10)),
+			},
+			expectedOutput: `{"tier": "gold", "discount": 10}`,
+		},
+		"skip modification when value does not equal": {
+			input: `{"tier": "silver"}`,
+			modifications: []JSONModification{
+				When(Equals("tier", "gold"), Set("discount", 10)),
+			},
+			expectedOutput: `{"tier": "silver"}`,
+		},
+		"compare numbers of different go types": {
+			input: `{"questsAchieved": 3}`,
+			modifications: []JSONModification{
+				When(Equals("questsAchieved", 3), Set("title", "Knight")),
+			},
+			expectedOutput: `{"questsAchieved": 3, "title": "Knight"}`,
+		},
+		"remove value when it is null": {
+			input: `{"name": "Perceval", "legacyId": null}`,
+			modifications: []JSONModification{
+				When(HasType("legacyId", JSONNull), Remove("legacyId")),
+			},
+			expectedOutput: `{"name": "Perceval"}`,
+		},
+		"keep value when it is not null": {
+			input: `{"name": "Perceval", "legacyId": 12}`,
+			modifications: []JSONModification{
+				When(Equals("legacyId", nil), Remove("legacyId")),
+			},
+			expectedOutput: `{"name": "Perceval", "legacyId": 12}`,
+		},
+		"apply modification unless value exists": {
+			input: `{"name": "Perceval"}`,
+			modifications: []JSONModification{
+				Unless(Exists("title"), Set("title", "Knight")),
+				Unless(Exists("name"), Set("name", "Karadoc")),
+			},
+			expectedOutput: `{"name": "Perceval", "title": "Knight"}`,
+		},
+		"missing intermediate segment does not exist": {
+			input: `{"manager": "Arthur"}`,
+			modifications: []JSONModification{
+				When(Exists("manager.name"), Remove("manager")),
+			},
+			expectedOutput: `{"manager": "Arthur"}`,
+		},
+		"apply modification when value matches": {
+			input: `{"email": "perceval@kaamelott.fr"}`,
+			modifications: []JSONModification{
+				When(Matches("email", `@kaamelott\.fr$`), Set("internal", true)),
+				When(Matches("missing", `.*`), Set("missing", true)),
+			},
+			expectedOutput: `{"email": "perceval@kaamelott.fr", "internal": true}`,
+		},
+		"nested and combined conditions": {
+			input: `{"knights": [{"name": "Perceval", "tier": "gold"}]}`,
+			modifications: []JSONModification{
+				When(
+					Or(Equals("knights[0].tier", "silver"), HasType("knights", JSONArray)),
+					When(And(Exists("knights[0].name"), Not(Exists("knights[1]"))), Set("knights[1].name", "Karadoc")),
+				),
+			},
+			expectedOutput: `{"knights": [{"name": "Perceval", "tier": "gold"}, {"name": "Karadoc"}]}`,
+		},
+		"invalid path in predicate": {
+			input: `{}`,
+			modifications: []JSONModification{
+				When(Exists("a..b"), Remove("a")),
+			},
+			expectedError: errors.New(`cannot parse json path ["a..b"], it doesn't seem valid`),
+		},
+		"invalid pattern in predicate": {
+			input: `{"name": "Perceval"}`,
+			modifications: []JSONModification{
+				Unless(Matches("name", `(`), Remove("name")),
+			},
+			expectedError: errors.New("cannot compile pattern [\"(\"]: error parsing regexp: missing closing ): `(`"),
+		},
+		"error in conditional modification": {
+			input: `{"name": "Perceval"}`,
+			modifications: []JSONModification{
+				When(Exists("name"), Remove("name.first")),
+			},
+			expectedError: errors.New("invalid path"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := Modify(test.input, test.modifications...)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if output == test.expectedOutput {
+				return
+			}
+			if message, ok := JSONEqual(output, test.expectedOutput); !ok {
+				t.Error("unexpected output: " + message)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"strconv"
+	"unicode/utf8"
+)
+
+// maxDecodingDepth is the nesting depth beyond which decodeJSON leaves documents to encoding/json, which rejects them
+const maxDecodingDepth = 10000
+
+// unmarshalString decodes a JSON document into untyped data, as json.Unmarshal does
+func unmarshalString(input string) (interface{}, error) {
+	if decoded, ok := decodeJSON(input); ok {
+		return decoded, nil
+	}
+	var decoded interface{}
+	err := json.Unmarshal([]byte(input), &decoded)
+	return decoded, err
+}
+
+// decodeJSON decodes a JSON document into the same untyped data as json.Unmarshal, without copying the input:
+// strings and keys that need no unescaping share the memory of input. It returns false for invalid documents,
+// so that json.Unmarshal can report the error.
+func decodeJSON(input string) (interface{}, bool) {
+	decoder := &jsonDecoder{input: input}
+	value, ok := decoder.value()
+	if !ok || skipWhitespace(input, decoder.position) != len(input) {
+		return nil, false
+	}
+	return value, true
+}
+
+type jsonDecoder struct {
+	input    string
+	position int
+	depth    int
+}
+
+func (d *jsonDecoder) value() (interface{}, bool) {
+	d.position = skipWhitespace(d.input, d.position)
+	if d.position == len(d.input) {
+		return nil, false
+	}
+	switch d.input[d.position] {
+	case '{':
+		return d.object()
+	case '[':
+		return d.array()
+	case '"':
+		return d.string()
+	case 't':
+		return true, d.literal("true")
+	case 'f':
+		return false, d.literal("false")
+	case 'n':
+		return nil, d.literal("null")
+	default:
+		return d.number()
+	}
+}
+
+func (d *jsonDecoder) literal(literal string) bool {
+	if len(d.input)-d.position < len(literal) || d.input[d.position:d.position+len(literal)] != literal {
+		return false
+	}
+	d.position += len(literal)
+	return true
+}
+
+func (d *jsonDecoder) object() (interface{}, bool) {
+	if d.depth++; d.depth > maxDecodingDepth {
+		return nil, false
+	}
+	defer func() { d.depth-- }()
+
+	object := make(map[string]interface{})
+	d.position = skipWhitespace(d.input, d.position+1)
+	if d.position < len(d.input) && d.input[d.position] == '}' {
+		d.position++
+		return object, true
+	}
+	for {
+		d.position = skipWhitespace(d.input, d.position)
+		if d.position == len(d.input) || d.input[d.position] != '"' {
+			return nil, false
+		}
+		key, ok := d.string()
+		if !ok {
+			return nil, false
+		}
+		d.position = skipWhitespace(d.input, d.position)
+		if d.position == len(d.input) || d.input[d.position] != ':' {
+			return nil, false
+		}
+		d.position++
+		value, ok := d.value()
+		if !ok {
+			return nil, false
+		}
+		object[key.(string)] = value
+
+		d.position = skipWhitespace(d.input, d.position)
+		if d.position == len(d.input) {
+			return nil, false
+		}
+		d.position++
+		switch d.input[d.position-1] {
+		case ',':
+		case '}':
+			return object, true
+		default:
+			return nil, false
+		}
+	}
+}
+
+func (d *jsonDecoder) array() (interface{}, bool) {
+	if d.depth++; d.depth > maxDecodingDepth {
+		return nil, false
+	}
+	defer func() { d.depth-- }()
+
+	array := make([]interface{}, 0)
+	d.position = skipWhitespace(d.input, d.position+1)
+	if d.position < len(d.input) && d.input[d.position] == ']' {
+		d.position++
+		return array, true
+	}
+	for {
+		value, ok := d.value()
+		if !ok {
+			return nil, false
+		}
+		array = append(array, value)
+
+		d.position = skipWhitespace(d.input, d.position)
+		if d.position == len(d.input) {
+			return nil, false
+		}
+		d.position++
+		switch d.input[d.position-1] {
+		case ',':
+		case ']':
+			return array, true
+		default:
+			return nil, false
+		}
+	}
+}
+
+// string decodes the string starting at the current position. Strings with escape sequences
+// or invalid UTF-8 are decoded by encoding/json, which unescapes them or replaces invalid bytes.
+func (d *jsonDecoder) string() (interface{}, bool) {
+	start := d.position
+	plain := true
+	for d.position++; d.position < len(d.input); d.position++ {
+		switch character := d.input[d.position]; {
+		case character == '"':
+			d.position++
+			raw := d.input[start:d.position]
+			if plain && utf8.ValidString(raw) {
+				return raw[1 : len(raw)-1], true
+			}
+			var decoded string
+			return decoded, json.Unmarshal([]byte(raw), &decoded) == nil
+		case character == '\\':
+			plain = false
+			d.position++
+		case character < 0x20:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// number decodes the number starting at the current position, which must follow the JSON grammar
+func (d *jsonDecoder) number() (interface{}, bool) {
+	number, ok := d.float()
+	return number, ok
+}
+
+// float decodes the number starting at the current position, without boxing it
+func (d *jsonDecoder) float() (float64, bool) {
+	start := d.position
+	digits := func() bool {
+		digitsStart := d.position
+		for d.position < len(d.input) && '0' <= d.input[d.position] && d.input[d.position] <= '9' {
+			d.position++
+		}
+		return d.position > digitsStart
+	}
+
+	if d.position < len(d.input) && d.input[d.position] == '-' {
+		d.position++
+	}
+	if d.position < len(d.input) && d.input[d.position] == '0' {
+		d.position++
+	} else if !digits() {
+		return 0, false
+	}
+	if d.position < len(d.input) && d.input[d.position] == '.' {
+		d.position++
+		if !digits() {
+			return 0, false
+		}
+	}
+	if d.position < len(d.input) && (d.input[d.position] == 'e' || d.input[d.position] == 'E') {
+		d.position++
+		if d.position < len(d.input) && (d.input[d.position] == '+' || d.input[d.position] == '-') {
+			d.position++
+		}
+		if !digits() {
+			return 0, false
+		}
+	}
+
+	number, err := strconv.ParseFloat(d.input[start:d.position], 64)
+	return number, err == nil
+}
@@ -0,0 +1,62 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		decoded bool
+	}{
+		"object":                      {input: ` { "name" : "Perceval", "quests": [1, 2.5, -0.5e3, 1E+2], "king": false, "manager": null, "x": true } `, decoded: true},
+		"empty containers":            {input: `{"a": {}, "b": [ ]}`, decoded: true},
+		"duplicate keys":              {input: `{"a": 1, "a": 2}`, decoded: true},
+		"unicode":                     {input: `"Provençal 🏰"`, decoded: true},
+		"escaped string":              {input: `{"a\"b": "<\n🏰"}`, decoded: true},
+		"invalid utf-8":               {input: "\"a\xffb\"", decoded: true},
+		"number at the end":           {input: `12`, decoded: true},
+		"empty":                       {input: ``},
+		"unfinished object":           {input: `{"a": 1`},
+		"trailing comma":              {input: `[1, 2,]`},
+		"missing colon":               {input: `{"a" 1}`},
+		"unquoted key":                {input: `{a: 1}`},
+		"control character in string": {input: "\"a\nb\""},
+		"invalid escape":              {input: `"\x"`},
+		"leading zero":                {input: `01`},
+		"missing fraction digits":     {input: `1.`},
+		"missing exponent digits":     {input: `1e+`},
+		"plus sign":                   {input: `+1`},
+		"hexadecimal":                 {input: `0x10`},
+		"out of range number":         {input: `1e400`},
+		"truncated literal":           {input: `tru`},
+		"trailing characters":         {input: `{} {}`},
+		"nesting deeper than encoding/json accepts": {input: strings.Repeat("[", 10001) + strings.Repeat("]", 10001)},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			decoded, ok := decodeJSON(test.input)
+			if ok != test.decoded {
+				t.Fatalf("unexpected decoding success: wanted [%v], got [%v]", test.decoded, ok)
+			}
+
+			var expected interface{}
+			expectedErr := json.Unmarshal([]byte(test.input), &expected)
+			if !ok {
+				if expectedErr == nil {
+					t.Error("expected encoding/json to reject the input")
+				}
+				return
+			}
+			if expectedErr != nil {
+				t.Fatalf("unexpected error: %v", expectedErr)
+			}
+			if diff := DeepEqual(decoded, expected); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,796 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Dialect is a syntax of JSON that ModifyDialect can read
+type Dialect int
+
+// Dialects
+const (
+	// DialectJSON is strict JSON, as read by Modify
+	DialectJSON Dialect = iota
+	// DialectJSONC is JSON with // and /* */ comments, and trailing commas
+	DialectJSONC
+	// DialectJSON5 is JSONC with unquoted keys, single quoted strings, escaped line breaks in strings,
+	// hexadecimal numbers, and numbers with a leading plus sign or a leading or trailing decimal point
+	DialectJSON5
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case DialectJSONC:
+		return "jsonc"
+	case DialectJSON5:
+		return "json5"
+	default:
+		return "json"
+	}
+}
+
+// DialectOptions configures ModifyDialect
+type DialectOptions struct {
+	// Input is the dialect of the input
+	Input Dialect
+	// KeepDialect writes the output in the dialect of the input instead of strict JSON.
+	// Comments stay next to the elements they annotate, and elements left untouched by modifications
+	// are written as they were; new elements are written as JSON, which is valid in every dialect.
+	KeepDialect bool
+	// DuplicateKeys is what to do with objects that have several members with the same key
+	DuplicateKeys DuplicateKeyHandling
+}
+
+// DuplicateKeyHandling defines what ModifyDialect does with objects that have several members with the same key
+type DuplicateKeyHandling int
+
+// Duplicate key handlings
+const (
+	// DuplicateKeysKeepLast keeps the value of the last member at the position of the first one, as json.Unmarshal does
+	DuplicateKeysKeepLast DuplicateKeyHandling = iota
+	// DuplicateKeysReject fails on the first duplicate key, giving its line and column
+	DuplicateKeysReject
+	// DuplicateKeysKeepFirst keeps the first member and leaves out the others
+	DuplicateKeysKeepFirst
+	// DuplicateKeysKeepAll gathers the values of the members in an array at the position of the first one, in order
+	DuplicateKeysKeepAll
+	// DuplicateKeysPreserve writes all the members back, as with KeepDialect. Modifications see the value of the last member,
+	// which is the only one they change; the others are written as they were, unless the key is removed.
+	DuplicateKeysPreserve
+)
+
+var (
+	json5DecimalRegexp     = regexp.MustCompile(`^[+-]?(?:[0-9]+\.?[0-9]*|\.[0-9]+)(?:[eE][+-]?[0-9]+)?$`)
+	json5HexadecimalRegexp = regexp.MustCompile(`^[+-]?0[xX][0-9a-fA-F]+$`)
+	jsonNumberRegexp       = regexp.MustCompile(`^-?(?:0|[1-9][0-9]*)(?:\.[0-9]+)?(?:[eE][+-]?[0-9]+)?$`)
+	json5IdentifierRegexp  = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*$`)
+)
+
+// ModifyDialect applies modifications to a string written in a dialect of JSON, such as JSONC or JSON5.
+// The output is strict JSON, as with Modify, unless options ask to keep the dialect of the input.
+// Options also tell what to do with duplicate keys, which Modify resolves by keeping the last one;
+// they apply to strict JSON input too.
+func ModifyDialect(input string, options DialectOptions, modifications ...JSONModification) (string, error) {
+	parser := &relaxedParser{input: input, dialect: options.Input, duplicateKeys: options.DuplicateKeys}
+	document, err := parser.parseDocument()
+	if err != nil {
+		return "", err
+	}
+
+	modified, err := Apply(document.root.toValue(), modifications...)
+	if err != nil {
+		return "", err
+	}
+	if !options.KeepDialect && options.DuplicateKeys != DuplicateKeysPreserve {
+		output, err := json.Marshal(modified)
+		return string(output), err
+	}
+	if document.root, err = reconcileRelaxedNode(document.root, modified); err != nil {
+		return "", err
+	}
+	return document.String(), nil
+}
+
+type relaxedKind int
+
+const (
+	relaxedScalar relaxedKind = iota
+	relaxedObject
+	relaxedArray
+)
+
+// relaxedNode is a value of a document written in a dialect of JSON, along with what is needed to write it back
+type relaxedNode struct {
+	kind relaxedKind
+	// raw is the source text of a scalar
+	raw string
+	// value is the decoded value of a scalar
+	value interface{}
+	// entries are the members of an object or the elements of an array
+	entries []*relaxedEntry
+	// endComments are the comments that follow the last entry of an object or array
+	endComments []string
+	// multiline is true if the object or array is written on several lines
+	multiline     bool
+	trailingComma bool
+	// original is the value returned by toValue, which the elements of modified arrays are matched with
+	original interface{}
+}
+
+// relaxedEntry is a member of an object or an element of an array, along with the comments that annotate it
+type relaxedEntry struct {
+	// rawKey is the source text of the key of an object member, and key its decoded value
+	rawKey string
+	key    string
+	node   *relaxedNode
+	// leading are the comments before the entry, and trailing the comment that follows it on the same line
+	leading  []string
+	trailing string
+	// blankLine is true if a blank line separates the entry from the previous one
+	blankLine bool
+}
+
+type relaxedDocument struct {
+	leading      []string
+	root         *relaxedNode
+	trailing     string
+	endComments  []string
+	indent       string
+	finalNewline bool
+}
+
+// toValue converts the node to untyped JSON data, and keeps it as the original value of the node
+func (n *relaxedNode) toValue() interface{} {
+	switch n.kind {
+	case relaxedObject:
+		object := make(map[string]interface{}, len(n.entries))
+		for _, entry := range n.entries {
+			object[entry.key] = entry.node.toValue()
+		}
+		n.original = object
+	case relaxedArray:
+		array := make([]interface{}, 0, len(n.entries))
+		for _, entry := range n.entries {
+			array = append(array, entry.node.toValue())
+		}
+		n.original = array
+	default:
+		n.original = n.value
+	}
+	return n.original
+}
+
+// hasLineComments tells whether comments that end with the line annotate the object or array
+func (n *relaxedNode) hasLineComments() bool {
+	for _, comment := range n.endComments {
+		if strings.HasPrefix(comment, "//") {
+			return true
+		}
+	}
+	for _, entry := range n.entries {
+		if strings.HasPrefix(entry.trailing, "//") {
+			return true
+		}
+		for _, comment := range entry.leading {
+			if strings.HasPrefix(comment, "//") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reconcileRelaxedNode returns a node holding value, keeping the source text and comments of node where possible
+func reconcileRelaxedNode(node *relaxedNode, value interface{}) (*relaxedNode, error) {
+	if node == nil {
+		return newRelaxedNode(value)
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if node.kind == relaxedObject {
+			keys := make([]string, len(node.entries))
+			for i, entry := range node.entries {
+				keys[i] = entry.key
+			}
+			states, added := planMembers(keys, value)
+			unchanged := membersUnchanged(states, added)
+			entries := make([]*relaxedEntry, 0, len(value))
+			for i, entry := range node.entries {
+				switch states[i] {
+				case memberShadowed:
+					entries = append(entries, entry)
+				case memberHeld:
+					reconciled, err := reconcileRelaxedEntry(entry, value[entry.key])
+					if err != nil {
+						return nil, err
+					}
+					unchanged = unchanged && reconciled == entry
+					entries = append(entries, reconciled)
+				}
+			}
+			for _, attribute := range added {
+				entry, err := newRelaxedMember(attribute, value[attribute])
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, entry)
+			}
+			return reconciledRelaxedContainer(node, entries, unchanged), nil
+		}
+	case []interface{}:
+		if node.kind == relaxedArray {
+			originals := make([]interface{}, len(node.entries))
+			for i, entry := range node.entries {
+				originals[i] = entry.node.original
+			}
+			matches := matchElements(originals, value)
+			unchanged := elementsUnchanged(matches, len(node.entries))
+			entries := make([]*relaxedEntry, 0, len(value))
+			for i, element := range value {
+				if matches[i] < 0 {
+					elementNode, err := newRelaxedNode(element)
+					if err != nil {
+						return nil, err
+					}
+					entries = append(entries, &relaxedEntry{node: elementNode})
+					continue
+				}
+				original := node.entries[matches[i]]
+				reconciled, err := reconcileRelaxedEntry(original, element)
+				if err != nil {
+					return nil, err
+				}
+				unchanged = unchanged && reconciled == original
+				entries = append(entries, reconciled)
+			}
+			return reconciledRelaxedContainer(node, entries, unchanged), nil
+		}
+	default:
+		if node.kind == relaxedScalar && sameScalar(node.value, value) {
+			return node, nil
+		}
+	}
+	return newRelaxedNode(value)
+}
+
+// reconcileRelaxedEntry returns entry, or a copy of it holding value if the value of its node changed
+func reconcileRelaxedEntry(entry *relaxedEntry, value interface{}) (*relaxedEntry, error) {
+	node, err := reconcileRelaxedNode(entry.node, value)
+	if err != nil || node == entry.node {
+		return entry, err
+	}
+	reconciled := *entry
+	reconciled.node = node
+	return &reconciled, nil
+}
+
+func reconciledRelaxedContainer(node *relaxedNode, entries []*relaxedEntry, unchanged bool) *relaxedNode {
+	if unchanged {
+		return node
+	}
+	reconciled := *node
+	reconciled.entries = entries
+	return &reconciled
+}
+
+// newRelaxedNode returns a node holding value, written as JSON
+func newRelaxedNode(value interface{}) (*relaxedNode, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		node := &relaxedNode{kind: relaxedObject}
+		for _, attribute := range sortedAttributes(value) {
+			entry, err := newRelaxedMember(attribute, value[attribute])
+			if err != nil {
+				return nil, err
+			}
+			node.entries = append(node.entries, entry)
+		}
+		return node, nil
+	case []interface{}:
+		node := &relaxedNode{kind: relaxedArray}
+		for _, element := range value {
+			elementNode, err := newRelaxedNode(element)
+			if err != nil {
+				return nil, err
+			}
+			node.entries = append(node.entries, &relaxedEntry{node: elementNode})
+		}
+		return node, nil
+	default:
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return &relaxedNode{kind: relaxedScalar, raw: string(raw), value: value}, nil
+	}
+}
+
+func newRelaxedMember(attribute string, value interface{}) (*relaxedEntry, error) {
+	node, err := newRelaxedNode(value)
+	if err != nil {
+		return nil, err
+	}
+	rawKey, _ := json.Marshal(attribute)
+	return &relaxedEntry{rawKey: string(rawKey), key: attribute, node: node}, nil
+}
+
+// String writes the document back in its dialect
+func (d *relaxedDocument) String() string {
+	var builder strings.Builder
+	for _, comment := range d.leading {
+		builder.WriteString(comment + "\n")
+	}
+	d.writeNode(&builder, d.root, "")
+	if d.trailing != "" {
+		builder.WriteString(" " + d.trailing)
+	}
+	for _, comment := range d.endComments {
+		builder.WriteString("\n" + comment)
+	}
+	if d.finalNewline {
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+func (d *relaxedDocument) writeNode(builder *strings.Builder, node *relaxedNode, indent string) {
+	if node.kind == relaxedScalar {
+		builder.WriteString(node.raw)
+		return
+	}
+	opening, closing := "[", "]"
+	if node.kind == relaxedObject {
+		opening, closing = "{", "}"
+	}
+	builder.WriteString(opening)
+	defer builder.WriteString(closing)
+	if len(node.entries) == 0 && len(node.endComments) == 0 {
+		return
+	}
+
+	if !node.multiline && !node.hasLineComments() {
+		for i, entry := range node.entries {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			for _, comment := range entry.leading {
+				builder.WriteString(comment + " ")
+			}
+			if node.kind == relaxedObject {
+				builder.WriteString(entry.rawKey + ": ")
+			}
+			d.writeNode(builder, entry.node, indent)
+			if entry.trailing != "" {
+				builder.WriteString(" " + entry.trailing)
+			}
+		}
+		for _, comment := range node.endComments {
+			builder.WriteString(" " + comment)
+		}
+		return
+	}
+
+	inner := indent + d.indent
+	builder.WriteString("\n")
+	for i, entry := range node.entries {
+		if i > 0 && entry.blankLine {
+			builder.WriteString("\n")
+		}
+		for _, comment := range entry.leading {
+			builder.WriteString(inner + comment + "\n")
+		}
+		builder.WriteString(inner)
+		if node.kind == relaxedObject {
+			builder.WriteString(entry.rawKey + ": ")
+		}
+		d.writeNode(builder, entry.node, inner)
+		if i < len(node.entries)-1 || node.trailingComma {
+			builder.WriteString(",")
+		}
+		if entry.trailing != "" {
+			builder.WriteString(" " + entry.trailing)
+		}
+		builder.WriteString("\n")
+	}
+	for _, comment := range node.endComments {
+		builder.WriteString(inner + comment + "\n")
+	}
+	builder.WriteString(indent)
+}
+
+// relaxedParser parses documents written in JSONC or JSON5
+type relaxedParser struct {
+	input         string
+	dialect       Dialect
+	duplicateKeys DuplicateKeyHandling
+	position      int
+	// blankLine is set when skipSpace skips a blank line
+	blankLine bool
+}
+
+func (p *relaxedParser) errorf(format string, args ...interface{}) error {
+	line := 1 + strings.Count(p.input[:p.position], "\n")
+	column := p.position - strings.LastIndex(p.input[:p.position], "\n")
+	return fmt.Errorf("cannot parse %v at line %d, column %d: %s", p.dialect, line, column, fmt.Sprintf(format, args...))
+}
+
+func (p *relaxedParser) parseDocument() (*relaxedDocument, error) {
+	document := &relaxedDocument{indent: detectIndent(p.input), finalNewline: strings.HasSuffix(p.input, "\n")}
+	var err error
+	if document.leading, err = p.skipSpace(); err != nil {
+		return nil, err
+	}
+	if document.root, err = p.parseValue(); err != nil {
+		return nil, err
+	}
+	if document.trailing, err = p.sameLineComment(); err != nil {
+		return nil, err
+	}
+	if document.endComments, err = p.skipSpace(); err != nil {
+		return nil, err
+	}
+	if p.position != len(p.input) {
+		return nil, p.errorf("unexpected character %q after top-level value", p.input[p.position])
+	}
+	return document, nil
+}
+
+// detectIndent returns the indentation of the first indented line of input, or two spaces
+func detectIndent(input string) string {
+	for _, line := range strings.Split(input, "\n")[1:] {
+		if trimmed := strings.TrimLeft(line, " \t"); trimmed != line && trimmed != "" {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
+
+// skipSpace skips whitespace and comments, and returns the comments
+func (p *relaxedParser) skipSpace() ([]string, error) {
+	var comments []string
+	newlines := 0
+	for p.position < len(p.input) {
+		switch {
+		case p.input[p.position] == '\n':
+			p.position++
+			if newlines++; newlines > 1 {
+				p.blankLine = true
+			}
+		case strings.IndexByte(" \t\r", p.input[p.position]) != -1:
+			p.position++
+		case strings.HasPrefix(p.input[p.position:], "//"), strings.HasPrefix(p.input[p.position:], "/*"):
+			comment, err := p.parseComment()
+			if err != nil {
+				return nil, err
+			}
+			comments = append(comments, comment)
+			newlines = 0
+		default:
+			return comments, nil
+		}
+	}
+	return comments, nil
+}
+
+// sameLineComment returns the comment that follows on the current line, if any.
+// A comment followed by a value on the same line annotates that value instead, and is left to skipSpace.
+func (p *relaxedParser) sameLineComment() (string, error) {
+	start := p.position
+	p.skipBlanks()
+	if strings.HasPrefix(p.input[p.position:], "//") || strings.HasPrefix(p.input[p.position:], "/*") {
+		comment, err := p.parseComment()
+		if err != nil {
+			return "", err
+		}
+		end := p.position
+		p.skipBlanks()
+		if p.position == len(p.input) || strings.IndexByte("\r\n,]}/", p.input[p.position]) != -1 {
+			p.position = end
+			return comment, nil
+		}
+	}
+	p.position = start
+	return "", nil
+}
+
+// skipBlanks skips spaces and tabs
+func (p *relaxedParser) skipBlanks() {
+	for p.position < len(p.input) && (p.input[p.position] == ' ' || p.input[p.position] == '\t') {
+		p.position++
+	}
+}
+
+func (p *relaxedParser) parseComment() (string, error) {
+	if p.dialect == DialectJSON {
+		return "", p.errorf("unexpected comment")
+	}
+	start := p.position
+	if strings.HasPrefix(p.input[p.position:], "//") {
+		end := strings.IndexByte(p.input[p.position:], '\n')
+		if end == -1 {
+			end = len(p.input) - p.position
+		}
+		p.position += end
+		return strings.TrimRight(p.input[start:p.position], "\r"), nil
+	}
+	end := strings.Index(p.input[p.position+2:], "*/")
+	if end == -1 {
+		return "", p.errorf("unterminated comment")
+	}
+	p.position += end + 4
+	return p.input[start:p.position], nil
+}
+
+func (p *relaxedParser) parseValue() (*relaxedNode, error) {
+	if p.position == len(p.input) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch character := p.input[p.position]; {
+	case character == '{' || character == '[':
+		return p.parseContainer()
+	case character == '"' || character == '\'' && p.dialect == DialectJSON5:
+		raw, value, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &relaxedNode{kind: relaxedScalar, raw: raw, value: value}, nil
+	default:
+		return p.parseLiteral()
+	}
+}
+
+func (p *relaxedParser) parseLiteral() (*relaxedNode, error) {
+	start := p.position
+	for p.position < len(p.input) && isLiteralCharacter(p.input[p.position]) {
+		p.position++
+	}
+	raw := p.input[start:p.position]
+	node := &relaxedNode{kind: relaxedScalar, raw: raw}
+	switch {
+	case raw == "true" || raw == "false":
+		node.value = raw == "true"
+	case raw == "null":
+	case jsonNumberRegexp.MatchString(raw) || p.dialect == DialectJSON5 && json5DecimalRegexp.MatchString(raw):
+		number, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			p.position = start
+			return nil, p.errorf("invalid number %q: %v", raw, err)
+		}
+		node.value = number
+	case p.dialect == DialectJSON5 && json5HexadecimalRegexp.MatchString(raw):
+		number, err := strconv.ParseInt(strings.Replace(strings.ToLower(raw), "0x", "", 1), 16, 64)
+		if err != nil {
+			p.position = start
+			return nil, p.errorf("invalid number %q: %v", raw, err)
+		}
+		node.value = float64(number)
+	default:
+		p.position = start
+		if raw == "" {
+			return nil, p.errorf("unexpected character %q", p.input[p.position])
+		}
+		return nil, p.errorf("invalid value %q", raw)
+	}
+	return node, nil
+}
+
+func isLiteralCharacter(character byte) bool {
+	return 'a' <= character && character <= 'z' || 'A' <= character && character <= 'Z' || '0' <= character && character <= '9' ||
+		strings.IndexByte("+-._$", character) != -1
+}
+
+// parseContainer parses an object or an array, attaching comments to its entries
+func (p *relaxedParser) parseContainer() (*relaxedNode, error) {
+	start := p.position
+	node := &relaxedNode{kind: relaxedArray}
+	closing := byte(']')
+	if p.input[p.position] == '{' {
+		node.kind, closing = relaxedObject, '}'
+	}
+	p.position++
+
+	var pending []string
+	// indexes are the indexes of the entries of an object by key, and gathered the keys whose values are gathered in an array
+	indexes, gathered := map[string]int{}, map[string]bool{}
+	for {
+		comments, err := p.skipSpace()
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, comments...)
+		if p.position == len(p.input) {
+			return nil, p.errorf("unexpected end of input")
+		}
+		if p.input[p.position] == closing {
+			if node.trailingComma && p.dialect == DialectJSON {
+				return nil, p.errorf("unexpected trailing comma")
+			}
+			break
+		}
+		if len(node.entries) != 0 && !node.trailingComma {
+			return nil, p.errorf("expected %q or %q, found %q", ',', closing, p.input[p.position])
+		}
+		node.trailingComma = false
+
+		entry := &relaxedEntry{leading: pending, blankLine: p.blankLine}
+		pending = nil
+		if node.kind == relaxedObject {
+			keyStart := p.position
+			if err := p.parseKey(entry); err != nil {
+				return nil, err
+			}
+			if _, ok := indexes[entry.key]; ok && p.duplicateKeys == DuplicateKeysReject {
+				p.position = keyStart
+				return nil, p.errorf("duplicate key [%q]", entry.key)
+			}
+			if comments, err = p.skipSpace(); err != nil {
+				return nil, err
+			}
+			entry.leading = append(entry.leading, comments...)
+			if p.position == len(p.input) || p.input[p.position] != ':' {
+				return nil, p.errorf("expected ':' after object key")
+			}
+			p.position++
+		}
+		if comments, err = p.skipSpace(); err != nil {
+			return nil, err
+		}
+		entry.leading = append(entry.leading, comments...)
+		if entry.node, err = p.parseValue(); err != nil {
+			return nil, err
+		}
+		index, duplicated := indexes[entry.key]
+		switch {
+		case node.kind == relaxedArray:
+			node.entries = append(node.entries, entry)
+		case !duplicated || p.duplicateKeys == DuplicateKeysPreserve:
+			indexes[entry.key] = len(node.entries)
+			node.entries = append(node.entries, entry)
+		case p.duplicateKeys == DuplicateKeysKeepLast:
+			node.entries[index].node = entry.node
+		case p.duplicateKeys == DuplicateKeysKeepAll:
+			first := node.entries[index]
+			if !gathered[entry.key] {
+				gathered[entry.key] = true
+				first.node = &relaxedNode{kind: relaxedArray, entries: []*relaxedEntry{{node: first.node}}}
+			}
+			first.node.entries = append(first.node.entries, &relaxedEntry{node: entry.node})
+		}
+
+		if entry.trailing, err = p.sameLineComment(); err != nil {
+			return nil, err
+		}
+		p.blankLine = false
+		if comments, err = p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.position < len(p.input) && p.input[p.position] == ',' {
+			p.position++
+			node.trailingComma = true
+			if entry.trailing == "" {
+				if entry.trailing, err = p.sameLineComment(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		pending = comments
+	}
+
+	node.endComments = pending
+	p.position++
+	node.multiline = strings.Contains(p.input[start:p.position], "\n")
+	return node, nil
+}
+
+func (p *relaxedParser) parseKey(entry *relaxedEntry) error {
+	if p.input[p.position] == '"' || p.input[p.position] == '\'' && p.dialect == DialectJSON5 {
+		raw, key, err := p.parseString()
+		if err != nil {
+			return err
+		}
+		entry.rawKey, entry.key = raw, key
+		return nil
+	}
+	start := p.position
+	for p.position < len(p.input) && isLiteralCharacter(p.input[p.position]) {
+		p.position++
+	}
+	raw := p.input[start:p.position]
+	if p.dialect != DialectJSON5 || !json5IdentifierRegexp.MatchString(raw) {
+		p.position = start
+		return p.errorf("invalid object key")
+	}
+	entry.rawKey, entry.key = raw, raw
+	return nil
+}
+
+// parseString parses a quoted string, and returns its source text and its value
+func (p *relaxedParser) parseString() (string, string, error) {
+	start := p.position
+	quote := p.input[p.position]
+	var value strings.Builder
+	for p.position++; p.position < len(p.input); p.position++ {
+		character := p.input[p.position]
+		switch {
+		case character == quote:
+			p.position++
+			return p.input[start:p.position], value.String(), nil
+		case character < 0x20:
+			return "", "", p.errorf("invalid control character in string")
+		case character != '\\':
+			value.WriteByte(character)
+			continue
+		}
+
+		p.position++
+		if p.position == len(p.input) {
+			break
+		}
+		escaped := p.input[p.position]
+		if p.dialect != DialectJSON5 && strings.IndexByte(`"\/bfnrtu`, escaped) == -1 {
+			return "", "", p.errorf("invalid escape sequence %q", "\\"+string(escaped))
+		}
+		switch escaped {
+		case 'b':
+			value.WriteByte('\b')
+		case 'f':
+			value.WriteByte('\f')
+		case 'n':
+			value.WriteByte('\n')
+		case 'r':
+			value.WriteByte('\r')
+		case 't':
+			value.WriteByte('\t')
+		case 'v':
+			value.WriteByte('\v')
+		case '0':
+			value.WriteByte(0)
+		case '\n':
+		case '\r':
+			if strings.HasPrefix(p.input[p.position:], "\r\n") {
+				p.position++
+			}
+		case 'u':
+			r, err := p.parseUnicodeEscape()
+			if err != nil {
+				return "", "", err
+			}
+			value.WriteRune(r)
+		default:
+			value.WriteByte(escaped)
+		}
+	}
+	return "", "", p.errorf("unterminated string")
+}
+
+// parseUnicodeEscape parses the digits of a \u escape sequence, and of the following one for surrogate pairs
+func (p *relaxedParser) parseUnicodeEscape() (rune, error) {
+	parseDigits := func(position int) (rune, bool) {
+		if position+4 > len(p.input) {
+			return 0, false
+		}
+		code, err := strconv.ParseUint(p.input[position:position+4], 16, 16)
+		return rune(code), err == nil
+	}
+	r, ok := parseDigits(p.position + 1)
+	if !ok {
+		return 0, p.errorf("invalid unicode escape sequence")
+	}
+	p.position += 4
+	if utf16.IsSurrogate(r) && strings.HasPrefix(p.input[p.position+1:], `\u`) {
+		if second, ok := parseDigits(p.position + 3); ok {
+			if decoded := utf16.DecodeRune(r, second); decoded != unicode.ReplacementChar {
+				p.position += 6
+				return decoded, nil
+			}
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,211 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestModifyDialect(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		options        DialectOptions
+		modifications  []JSONModification
+		expectedOutput string
+		expectedError  error
+	}{
+		"jsonc to strict json": {
+			input: `{
+  // knight of the Round Table
+  "name": "Perceval", /* aka Provençal le Gaulois */
+  "quests": [1, 2,],
+}`,
+			options:        DialectOptions{Input: DialectJSONC},
+			modifications:  []JSONModification{Set("quests[2]", 3)},
+			expectedOutput: `{"name":"Perceval","quests":[1,2,3]}`,
+		},
+		"json5 to strict json": {
+			input:          `{name: 'Karadoc', 'hunger': +.5, code: 0xFF, food: 'sau\` + "\n" + `cisson'}`,
+			options:        DialectOptions{Input: DialectJSON5},
+			expectedOutput: `{"code":255,"food":"saucisson","hunger":0.5,"name":"Karadoc"}`,
+		},
+		"jsonc kept with its comments": {
+			input: `// knights
+{
+  // first knight
+  "name": "Perceval", // aka Provençal le Gaulois
+  "quests": [1, 2],
+  "retired": false,
+  /* more to come */
+}
+`,
+			options:       DialectOptions{Input: DialectJSONC, KeepDialect: true},
+			modifications: []JSONModification{Set("name", "Karadoc"), Set("quests[2]", 3), Remove("retired"), Set("manager.name", "Arthur")},
+			expectedOutput: `// knights
+{
+  // first knight
+  "name": "Karadoc", // aka Provençal le Gaulois
+  "quests": [1, 2, 3],
+  "manager": {"name": "Arthur"},
+  /* more to come */
+}
+`,
+		},
+		"json5 kept with untouched values written as they were": {
+			input: `{
+	name: 'Perceval',
+	code: 0x2A, // the answer
+	titles: [
+		'Knight', // of the Round Table
+	],
+}`,
+			options:       DialectOptions{Input: DialectJSON5, KeepDialect: true},
+			modifications: []JSONModification{Set("titles[1]", "Provençal le Gaulois")},
+			expectedOutput: `{
+	name: 'Perceval',
+	code: 0x2A, // the answer
+	titles: [
+		'Knight', // of the Round Table
+		"Provençal le Gaulois",
+	],
+}`,
+		},
+		"block comments before a value stay with it": {
+			input:          `[1, /* x */ 2, 3 /* y */, 4 /* z */]`,
+			options:        DialectOptions{Input: DialectJSONC, KeepDialect: true},
+			modifications:  []JSONModification{Set("[1]", 5), Set("[3]", 6)},
+			expectedOutput: `[1, /* x */ 5, 3 /* y */, 6 /* z */]`,
+		},
+		"comments stay with the elements that remain after a removal": {
+			input:          "[\n  // first\n  1,\n  // second\n  2,\n]",
+			options:        DialectOptions{Input: DialectJSONC, KeepDialect: true},
+			modifications:  []JSONModification{Remove("[0]")},
+			expectedOutput: "[\n  // second\n  2,\n]",
+		},
+		"trailing comment of a removed element is removed with it": {
+			input:          "[1, 2, // two\n  3]",
+			options:        DialectOptions{Input: DialectJSONC, KeepDialect: true},
+			modifications:  []JSONModification{Remove("[1]")},
+			expectedOutput: "[\n  1,\n  3\n]",
+		},
+		"block comments follow removed elements": {
+			input:          `[1, /* x */ 2, 3 /* y */, 4 /* z */]`,
+			options:        DialectOptions{Input: DialectJSONC, KeepDialect: true},
+			modifications:  []JSONModification{Remove("[2]")},
+			expectedOutput: `[1, /* x */ 2, 4 /* z */]`,
+		},
+		"blank lines between members are kept": {
+			input: `{
+  "name": "Perceval",
+
+  // quests
+  "quests": [1],
+  "retired": false,
+
+
+  "manager": null
+}
+`,
+			options:       DialectOptions{Input: DialectJSONC, KeepDialect: true},
+			modifications: []JSONModification{Set("quests[1]", 2), Remove("retired")},
+			expectedOutput: `{
+  "name": "Perceval",
+
+  // quests
+  "quests": [1, 2],
+
+  "manager": null
+}
+`,
+		},
+		"strict json": {
+			input:          `{"name": "Perceval"}`,
+			options:        DialectOptions{Input: DialectJSON},
+			modifications:  []JSONModification{Set("name", "Karadoc")},
+			expectedOutput: `{"name":"Karadoc"}`,
+		},
+		"unquoted keys are not jsonc": {
+			input:         `{name: "Perceval"}`,
+			options:       DialectOptions{Input: DialectJSONC},
+			expectedError: errors.New(`cannot parse jsonc at line 1, column 2: invalid object key`),
+		},
+		"unterminated comment": {
+			input:         "{\n  \"name\": 1 /* oops\n}",
+			options:       DialectOptions{Input: DialectJSON5},
+			expectedError: errors.New(`cannot parse json5 at line 2, column 13: unterminated comment`),
+		},
+		"missing comma": {
+			input:         `[1 2]`,
+			options:       DialectOptions{Input: DialectJSONC},
+			expectedError: errors.New(`cannot parse jsonc at line 1, column 4: expected ',' or ']', found '2'`),
+		},
+		"trailing comma in strict json": {
+			input:         `{"name": "Perceval",}`,
+			options:       DialectOptions{Input: DialectJSON, DuplicateKeys: DuplicateKeysReject},
+			expectedError: errors.New(`cannot parse json at line 1, column 21: unexpected trailing comma`),
+		},
+		"duplicate keys rejected": {
+			input:         "{\n  \"name\": \"Perceval\",\n  \"name\": \"Karadoc\"\n}",
+			options:       DialectOptions{DuplicateKeys: DuplicateKeysReject},
+			expectedError: errors.New(`cannot parse json at line 3, column 3: duplicate key ["name"]`),
+		},
+		"duplicate keys keeping the last": {
+			input:          `{"name": "Perceval", "title": "Knight", "name": "Karadoc"}`,
+			options:        DialectOptions{Input: DialectJSONC, KeepDialect: true},
+			modifications:  []JSONModification{Set("age", 40)},
+			expectedOutput: `{"name": "Karadoc", "title": "Knight", "age": 40}`,
+		},
+		"duplicate keys of strict json keeping the last": {
+			input:          `{"a":1,"a":2}`,
+			options:        DialectOptions{Input: DialectJSON},
+			modifications:  []JSONModification{Set("b", 2)},
+			expectedOutput: `{"a":2,"b":2}`,
+		},
+		"duplicate keys of strict json keeping the last and the dialect": {
+			input:          `{"a": 1, "a": 2}`,
+			options:        DialectOptions{Input: DialectJSON, KeepDialect: true},
+			modifications:  []JSONModification{Set("b", 2)},
+			expectedOutput: `{"a": 2, "b": 2}`,
+		},
+		"duplicate keys keeping the first": {
+			input:          `{"name": "Perceval", "name": "Karadoc"}`,
+			options:        DialectOptions{DuplicateKeys: DuplicateKeysKeepFirst},
+			expectedOutput: `{"name":"Perceval"}`,
+		},
+		"duplicate keys keeping all": {
+			input:          `{"name": "Perceval", "name": "Karadoc", "name": ["Arthur"]}`,
+			options:        DialectOptions{DuplicateKeys: DuplicateKeysKeepAll},
+			expectedOutput: `{"name":["Perceval","Karadoc",["Arthur"]]}`,
+		},
+		"duplicate keys preserved": {
+			input:          `{"name": "Perceval", "name": "Karadoc", "knights": [{"id": 1, "id": 2}]}`,
+			options:        DialectOptions{DuplicateKeys: DuplicateKeysPreserve},
+			modifications:  []JSONModification{Set("name", "Arthur"), Set("knights[0].rank", 1)},
+			expectedOutput: `{"name": "Perceval", "name": "Arthur", "knights": [{"id": 1, "id": 2, "rank": 1}]}`,
+		},
+		"removed duplicate keys": {
+			input:          `{"name": "Perceval", "name": "Karadoc", "age": 40}`,
+			options:        DialectOptions{DuplicateKeys: DuplicateKeysPreserve},
+			modifications:  []JSONModification{Remove("name")},
+			expectedOutput: `{"age": 40}`,
+		},
+		"failing modification": {
+			input:         `{"name": "Perceval"}`,
+			options:       DialectOptions{Input: DialectJSONC},
+			modifications: []JSONModification{Set("name.first", "Perceval")},
+			expectedError: errors.New("invalid path"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := ModifyDialect(test.input, test.options, test.modifications...)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if output != test.expectedOutput {
+				t.Errorf("unexpected output: wanted [%v], got [%v]", test.expectedOutput, output)
+			}
+		})
+	}
+}
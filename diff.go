@@ -0,0 +1,243 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// ChangeType is the kind of a Change
+type ChangeType string
+
+// Change types
+const (
+	ChangeAdd     ChangeType = "add"
+	ChangeRemove  ChangeType = "remove"
+	ChangeReplace ChangeType = "replace"
+)
+
+// Change is a difference between two JSON documents, at a given path
+type Change struct {
+	Type ChangeType `json:"type"`
+	// Path is the path of the changed element, using the same syntax as Set and Remove.
+	// It is empty when the whole document is replaced.
+	Path string `json:"path"`
+	// OldValue is the removed or replaced value
+	OldValue interface{} `json:"oldValue,omitempty"`
+	// NewValue is the added or replacing value
+	NewValue interface{} `json:"newValue,omitempty"`
+}
+
+var addressableAttributeRegexp = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+
+// Diff returns the changes that turn a into b.
+// Changes are meant to be applied in order: array indices take previous changes into account.
+// Arrays are compared using their longest common subsequence, so that inserting or removing
+// an element does not show up as a change of every following element.
+// Objects whose attributes cannot be addressed with a path are replaced as a whole.
+func Diff(a, b string) ([]Change, error) {
+	var parsedA, parsedB interface{}
+	if err := json.Unmarshal([]byte(a), &parsedA); err != nil {
+		return nil, fmt.Errorf("failed to parse first json: %w", err)
+	}
+	if err := json.Unmarshal([]byte(b), &parsedB); err != nil {
+		return nil, fmt.Errorf("failed to parse second json: %w", err)
+	}
+	return diff(parsedA, parsedB, ""), nil
+}
+
+// ToModification returns the modification that applies the change
+func (c Change) ToModification() JSONModification {
+	return func(toModify interface{}) (interface{}, error) {
+		pathSegments, err := parseJSONPathOrRoot(c.Path)
+		if err != nil {
+			return nil, err
+		}
+		switch c.Type {
+		case ChangeAdd:
+			if len(pathSegments) != 0 && pathSegments[len(pathSegments)-1].index != nil {
+				return insert(toModify, pathSegments, c.NewValue)
+			}
+			return set(toModify, pathSegments, c.NewValue)
+		case ChangeRemove:
+			if len(pathSegments) == 0 {
+				return nil, errors.New("cannot remove the whole document")
+			}
+			return remove(toModify, pathSegments)
+		case ChangeReplace:
+			return set(toModify, pathSegments, c.NewValue)
+		default:
+			return nil, fmt.Errorf("unknown change type [%q]", c.Type)
+		}
+	}
+}
+
+// String formats the change on a single line, prefixed by +, - or ~ depending on its type
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "(root)"
+	}
+	switch c.Type {
+	case ChangeAdd:
+		return fmt.Sprintf("+ %s: %s", path, compactJSON(c.NewValue))
+	case ChangeRemove:
+		return fmt.Sprintf("- %s: %s", path, compactJSON(c.OldValue))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", path, compactJSON(c.OldValue), compactJSON(c.NewValue))
+	}
+}
+
+func compactJSON(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
+
+// insert inserts value in an array, before the element at the given path
+func insert(toModify interface{}, parsedPath []jsonPathSegment, value interface{}) (interface{}, error) {
+	parentPath, index := parsedPath[:len(parsedPath)-1], *parsedPath[len(parsedPath)-1].index
+	parent, found := get(toModify, parentPath)
+	array, ok := parent.([]interface{})
+	if !found || parent == nil {
+		array, ok = []interface{}{}, true
+	}
+	if !ok {
+		return nil, errors.New("cannot insert into something that is not a JSON array")
+	}
+	if index < 0 || len(array) < index {
+		return nil, errors.New("out of bounds insertion index")
+	}
+	inserted := make([]interface{}, 0, len(array)+1)
+	inserted = append(append(inserted, array[:index]...), value)
+	inserted = append(inserted, array[index:]...)
+	return set(toModify, parentPath, inserted)
+}
+
+func diff(a, b interface{}, path string) []Change {
+	if reflect.DeepEqual(a, b) {
+		return nil
+	}
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			if changes, ok := diffObjects(a, b, path); ok {
+				return changes
+			}
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			return diffArrays(a, b, path)
+		}
+	}
+	return []Change{{Type: ChangeReplace, Path: path, OldValue: a, NewValue: b}}
+}
+
+// diffObjects returns the changes between two objects, or false if one of them cannot be expressed with a path
+func diffObjects(a, b map[string]interface{}, path string) ([]Change, bool) {
+	attributes := make([]string, 0, len(a)+len(b))
+	for attribute := range a {
+		attributes = append(attributes, attribute)
+	}
+	for attribute := range b {
+		if _, ok := a[attribute]; !ok {
+			attributes = append(attributes, attribute)
+		}
+	}
+	sort.Strings(attributes)
+
+	var changes []Change
+	for _, attribute := range attributes {
+		valueA, inA := a[attribute]
+		valueB, inB := b[attribute]
+		if inA && inB && reflect.DeepEqual(valueA, valueB) {
+			continue
+		}
+		if !addressableAttributeRegexp.MatchString(attribute) {
+			return nil, false
+		}
+		attributePath := attribute
+		if path != "" {
+			attributePath = path + "." + attribute
+		}
+		switch {
+		case !inA:
+			changes = append(changes, Change{Type: ChangeAdd, Path: attributePath, NewValue: valueB})
+		case !inB:
+			changes = append(changes, Change{Type: ChangeRemove, Path: attributePath, OldValue: valueA})
+		default:
+			changes = append(changes, diff(valueA, valueB, attributePath)...)
+		}
+	}
+	return changes, true
+}
+
+// diffArrays returns the changes between two arrays, based on their longest common subsequence.
+// Between two common elements, removed and added elements are paired into replacements first.
+func diffArrays(a, b []interface{}, path string) []Change {
+	commonLengths := longestCommonSubsequenceLengths(a, b)
+
+	var changes []Change
+	var removed, added []interface{}
+	position := 0
+	flush := func() {
+		for len(removed) != 0 && len(added) != 0 {
+			changes = append(changes, diff(removed[0], added[0], path+"["+strconv.Itoa(position)+"]")...)
+			removed, added = removed[1:], added[1:]
+			position++
+		}
+		for _, value := range removed {
+			changes = append(changes, Change{Type: ChangeRemove, Path: path + "[" + strconv.Itoa(position) + "]", OldValue: value})
+		}
+		for _, value := range added {
+			changes = append(changes, Change{Type: ChangeAdd, Path: path + "[" + strconv.Itoa(position) + "]", NewValue: value})
+			position++
+		}
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && reflect.DeepEqual(a[i], b[j]):
+			flush()
+			i, j = i+1, j+1
+			position++
+		case j == len(b) || (i < len(a) && commonLengths[i+1][j] >= commonLengths[i][j+1]):
+			removed = append(removed, a[i])
+			i++
+		default:
+			added = append(added, b[j])
+			j++
+		}
+	}
+	flush()
+	return changes
+}
+
+// longestCommonSubsequenceLengths returns a table whose [i][j] cell holds the length
+// of the longest common subsequence of a[i:] and b[j:]
+func longestCommonSubsequenceLengths(a, b []interface{}) [][]int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if reflect.DeepEqual(a[i], b[j]) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+	return lengths
+}
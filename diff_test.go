@@ -0,0 +1,152 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := map[string]struct {
+		a, b            string
+		expectedChanges []Change
+		expectedError   error
+	}{
+		"identical documents": {
+			a: `{"name": "Perceval", "titles": ["Knight"]}`,
+			b: `{"titles": ["Knight"], "name": "Perceval"}`,
+		},
+		"different primitive documents": {
+			a: `1`,
+			b: `"one"`,
+			expectedChanges: []Change{
+				{Type: ChangeReplace, Path: "", OldValue: float64(1), NewValue: "one"},
+			},
+		},
+		"object attribute changes": {
+			a: `{"name": "Perceval", "aka": "Provençal le Gaulois", "manager": {"name": "Arthur"}}`,
+			b: `{"name": "Perceval", "title": "Knight", "manager": {"name": "Léodagan"}}`,
+			expectedChanges: []Change{
+				{Type: ChangeRemove, Path: "aka", OldValue: "Provençal le Gaulois"},
+				{Type: ChangeReplace, Path: "manager.name", OldValue: "Arthur", NewValue: "Léodagan"},
+				{Type: ChangeAdd, Path: "title", NewValue: "Knight"},
+			},
+		},
+		"type change of an attribute": {
+			a: `{"manager": {"name": "Arthur"}}`,
+			b: `{"manager": ["Arthur"]}`,
+			expectedChanges: []Change{
+				{Type: ChangeReplace, Path: "manager", OldValue: map[string]interface{}{"name": "Arthur"}, NewValue: []interface{}{"Arthur"}},
+			},
+		},
+		"element inserted in the middle of an array": {
+			a: `{"knights": ["Perceval", "Karadoc", "Lancelot"]}`,
+			b: `{"knights": ["Perceval", "Bohort", "Karadoc", "Lancelot"]}`,
+			expectedChanges: []Change{
+				{Type: ChangeAdd, Path: "knights[1]", NewValue: "Bohort"},
+			},
+		},
+		"element removed from the middle of an array": {
+			a: `["Perceval", "Karadoc", "Lancelot"]`,
+			b: `["Perceval", "Lancelot"]`,
+			expectedChanges: []Change{
+				{Type: ChangeRemove, Path: "[1]", OldValue: "Karadoc"},
+			},
+		},
+		"element changed inside an array": {
+			a: `[{"name": "Perceval"}, {"name": "Karadoc"}, {"name": "Lancelot"}]`,
+			b: `[{"name": "Perceval"}, {"name": "Karadoc", "title": "Knight"}, {"name": "Lancelot"}]`,
+			expectedChanges: []Change{
+				{Type: ChangeAdd, Path: "[1].title", NewValue: "Knight"},
+			},
+		},
+		"mixed array changes": {
+			a: `[1, 2, 3, 4, 5]`,
+			b: `[0, 1, 3, 6, 7, 5]`,
+			expectedChanges: []Change{
+				{Type: ChangeAdd, Path: "[0]", NewValue: float64(0)},
+				{Type: ChangeRemove, Path: "[2]", OldValue: float64(2)},
+				{Type: ChangeReplace, Path: "[3]", OldValue: float64(4), NewValue: float64(6)},
+				{Type: ChangeAdd, Path: "[4]", NewValue: float64(7)},
+			},
+		},
+		"attribute that cannot be addressed": {
+			a: `{"knights": {"Perceval de Galles": 1}}`,
+			b: `{"knights": {"Perceval de Galles": 2}}`,
+			expectedChanges: []Change{
+				{
+					Type:     ChangeReplace,
+					Path:     "knights",
+					OldValue: map[string]interface{}{"Perceval de Galles": float64(1)},
+					NewValue: map[string]interface{}{"Perceval de Galles": float64(2)},
+				},
+			},
+		},
+		"invalid first json": {
+			a:             `{`,
+			b:             `{}`,
+			expectedError: errors.New("failed to parse first json: unexpected end of JSON input"),
+		},
+		"invalid second json": {
+			a:             `{}`,
+			b:             `{`,
+			expectedError: errors.New("failed to parse second json: unexpected end of JSON input"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			changes, err := Diff(test.a, test.b)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if diff := DeepEqual(changes, test.expectedChanges); diff != "" {
+				t.Errorf("unexpected changes: " + diff)
+			}
+			if err != nil {
+				return
+			}
+
+			modifications := make([]JSONModification, 0, len(changes))
+			for _, change := range changes {
+				modifications = append(modifications, change.ToModification())
+			}
+			output, err := Modify(test.a, modifications...)
+			if err != nil {
+				t.Errorf("failed to apply changes: %v", err)
+				return
+			}
+			if message, ok := JSONEqual(output, test.b); !ok {
+				t.Error("applying changes did not produce the second document: " + message)
+			}
+		})
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	tests := map[string]struct {
+		change         Change
+		expectedResult string
+	}{
+		"addition": {
+			change:         Change{Type: ChangeAdd, Path: "manager.name", NewValue: "Arthur"},
+			expectedResult: `+ manager.name: "Arthur"`,
+		},
+		"removal": {
+			change:         Change{Type: ChangeRemove, Path: "knights[1]", OldValue: map[string]interface{}{"name": "Karadoc"}},
+			expectedResult: `- knights[1]: {"name":"Karadoc"}`,
+		},
+		"replacement of the whole document": {
+			change:         Change{Type: ChangeReplace, OldValue: float64(1), NewValue: nil},
+			expectedResult: `~ (root): 1 -> null`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if result := test.change.String(); result != test.expectedResult {
+				t.Errorf("got result [%v], wanted [%v]", result, test.expectedResult)
+			}
+		})
+	}
+}
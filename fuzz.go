@@ -0,0 +1,73 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// maxFuzzMutations is the maximum number of mutations FuzzMutate applies, whatever the size of its input
+const maxFuzzMutations = 16
+
+// FuzzMutate turns data, as provided by a fuzzing engine such as the one of go test -fuzz,
+// into a sequence of mutations applied to document, which must be valid JSON.
+// Every byte of data steers which element is mutated and how, so the same data always produces
+// the same mutations, and the result is always valid JSON.
+// Mutations stop when data is exhausted or when nothing more can be mutated.
+//
+//	func FuzzHandler(f *testing.F) {
+//		f.Add([]byte{})
+//		f.Fuzz(func(t *testing.T, data []byte) {
+//			input, _, err := slowjsonmutator.FuzzMutate(validRequest, data)
+//			...
+//		})
+//	}
+func FuzzMutate(document string, data []byte) (string, []Mutation, error) {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(document), &parsed); err != nil {
+		return "", nil, err
+	}
+
+	chooser := &byteChooser{data: data}
+	mutator := &RandomMutator{random: chooser, kinds: AllMutationKinds}
+
+	var mutations []Mutation
+	for !chooser.exhausted() && len(mutations) < maxFuzzMutations {
+		mutation, err := mutator.nextMutation(parsed)
+		if errors.Is(err, errNoPossibleMutation) {
+			break
+		} else if err != nil {
+			return "", nil, err
+		}
+		if parsed, err = mutation.Modification(parsed); err != nil {
+			return "", nil, err
+		}
+		mutations = append(mutations, mutation)
+	}
+
+	result, err := json.Marshal(parsed)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(result), mutations, nil
+}
+
+// byteChooser picks numbers by consuming bytes of data, and picks 0 once it is exhausted
+type byteChooser struct {
+	data []byte
+}
+
+func (c *byteChooser) Intn(n int) int {
+	if n <= 1 {
+		return 0
+	}
+	value := 0
+	for bound := 1; bound < n && !c.exhausted(); bound <<= 8 {
+		value = value<<8 | int(c.data[0])
+		c.data = c.data[1:]
+	}
+	return value % n
+}
+
+func (c *byteChooser) exhausted() bool {
+	return len(c.data) == 0
+}
@@ -0,0 +1,28 @@
+//go:build go1.18
+// +build go1.18
+
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func FuzzFuzzMutate(f *testing.F) {
+	input := `{"name": "Perceval", "questsAchieved": 0, "manager": {"name": "Arthur", "titles": ["King", "Suzerain"]}}`
+	f.Add([]byte{})
+	f.Add([]byte{1, 2, 3, 4})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		output, mutations, err := FuzzMutate(input, data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !json.Valid([]byte(output)) {
+			t.Fatalf("invalid json produced: %v", output)
+		}
+		if reproduced, err := Modify(input, Modifications(mutations)...); err != nil || reproduced != output {
+			t.Fatalf("mutations produced [%v] (error: %v), wanted [%v]", reproduced, err, output)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestFuzzMutate(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		data           []byte
+		expectedOutput string
+		expectedCount  int
+		expectedError  error
+	}{
+		"no data": {
+			input:          `{"name": "Perceval"}`,
+			expectedOutput: `{"name":"Perceval"}`,
+		},
+		"drop the first field": {
+			input:          `{"name": "Perceval", "title": "Knight"}`,
+			data:           []byte{0},
+			expectedOutput: `{"title":"Knight"}`,
+			expectedCount:  1,
+		},
+		"nothing left to mutate": {
+			input:          `{"name": "Perceval"}`,
+			data:           []byte{0, 0, 0, 0, 0, 0},
+			expectedOutput: `{}`,
+			expectedCount:  1,
+		},
+		"nothing to mutate in a primitive document": {
+			input:          `12`,
+			data:           []byte{1, 2, 3},
+			expectedOutput: `12`,
+		},
+		"invalid document": {
+			input:         `{`,
+			data:          []byte{1},
+			expectedError: errors.New("unexpected end of JSON input"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, mutations, err := FuzzMutate(test.input, test.data)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if output != test.expectedOutput {
+				t.Errorf("got output [%v], wanted [%v]", output, test.expectedOutput)
+			}
+			if len(mutations) != test.expectedCount {
+				t.Errorf("got %d mutations, wanted %d", len(mutations), test.expectedCount)
+			}
+		})
+	}
+}
+
+func TestFuzzMutateIsDeterministicAndValid(t *testing.T) {
+	input := `{"name": "Perceval", "questsAchieved": 0, "manager": {"name": "Arthur", "titles": ["King", "Suzerain"]}}`
+	for i := 0; i < 200; i++ {
+		data := make([]byte, i%40)
+		for j := range data {
+			data[j] = byte(i*31 + j*7)
+		}
+
+		firstOutput, mutations, err := FuzzMutate(input, data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !json.Valid([]byte(firstOutput)) {
+			t.Errorf("invalid json produced from %v: %v", data, firstOutput)
+		}
+		if len(mutations) > maxFuzzMutations {
+			t.Errorf("got %d mutations, wanted at most %d", len(mutations), maxFuzzMutations)
+		}
+		secondOutput, _, _ := FuzzMutate(input, data)
+		if firstOutput != secondOutput {
+			t.Errorf("data %v produced different outputs: [%v] and [%v]", data, firstOutput, secondOutput)
+		}
+		reproduced, err := Modify(input, Modifications(mutations)...)
+		if err != nil || reproduced != firstOutput {
+			t.Errorf("mutations of data %v produced [%v] (error: %v), wanted [%v]", data, reproduced, err, firstOutput)
+		}
+	}
+}
@@ -0,0 +1,553 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxGenerationDepth is the depth beyond which optional properties and array items are not generated anymore,
+	// so that recursive schemas produce finite documents
+	maxGenerationDepth = 8
+	// maxGeneratedItems bounds the number of items generated for arrays without a small enough maxItems
+	maxGeneratedItems = 5
+	// maxGeneratedStringLength bounds the length of generated strings without a small enough maxLength
+	maxGeneratedStringLength = 16
+	// maxGeneratedRepetitions bounds the repetitions generated for unbounded pattern quantifiers
+	maxGeneratedRepetitions = 3
+	// generatedNumberRange is the width of the range numbers are picked in, when the schema leaves it open
+	generatedNumberRange = 100
+)
+
+// formatExamples are values generated for strings with a format
+var formatExamples = map[string]string{
+	"date-time":     "2021-01-01T00:00:00Z",
+	"date":          "2021-01-01",
+	"time":          "00:00:00Z",
+	"duration":      "P1D",
+	"email":         "perceval@example.com",
+	"idn-email":     "perceval@example.com",
+	"hostname":      "example.com",
+	"idn-hostname":  "example.com",
+	"ipv4":          "192.0.2.1",
+	"ipv6":          "2001:db8::1",
+	"uri":           "https://example.com/",
+	"uri-reference": "/kaamelott",
+	"iri":           "https://example.com/",
+	"iri-reference": "/kaamelott",
+	"uuid":          "123e4567-e89b-42d3-a456-426614174000",
+	"json-pointer":  "/knights/0",
+	"regex":         "^.*$",
+}
+
+// typeInferences are the types generated for schemas without a type, depending on their keywords
+var typeInferences = []struct {
+	keyword, inferredType string
+}{
+	{"properties", "object"}, {"required", "object"}, {"additionalProperties", "object"}, {"minProperties", "object"},
+	{"items", "array"}, {"prefixItems", "array"}, {"minItems", "array"}, {"contains", "array"},
+	{"pattern", "string"}, {"format", "string"}, {"minLength", "string"}, {"maxLength", "string"},
+	{"minimum", "number"}, {"maximum", "number"}, {"exclusiveMinimum", "number"}, {"exclusiveMaximum", "number"}, {"multipleOf", "number"},
+}
+
+type generationMode int
+
+const (
+	generateMinimal generationMode = iota
+	generateMaximal
+	generateRandom
+)
+
+type generator struct {
+	schema *Schema
+	mode   generationMode
+	random *rand.Rand
+}
+
+// GenerateMinimal generates a document that conforms to the schema, with as little content as possible:
+// only required properties, the fewest items allowed, the first allowed alternatives and the smallest values.
+// Generation supports a subset of JSON Schema, so it fails if the document it produces does not conform to the schema.
+func (s *Schema) GenerateMinimal() (string, error) {
+	return s.generate(&generator{schema: s, mode: generateMinimal})
+}
+
+// GenerateMaximal generates a document that conforms to the schema, with as much content as reasonable:
+// every known property and several items in arrays, up to a nesting limit for recursive schemas.
+// Generation supports a subset of JSON Schema, so it fails if the document it produces does not conform to the schema.
+func (s *Schema) GenerateMaximal() (string, error) {
+	return s.generate(&generator{schema: s, mode: generateMaximal})
+}
+
+// GenerateRandom generates a random document that conforms to the schema.
+// It is deterministic: the same seed always produces the same document.
+// Generation supports a subset of JSON Schema, so it fails if the document it produces does not conform to the schema.
+func (s *Schema) GenerateRandom(seed int64) (string, error) {
+	return s.generate(&generator{schema: s, mode: generateRandom, random: rand.New(rand.NewSource(seed))})
+}
+
+func (s *Schema) generate(g *generator) (string, error) {
+	generated, err := g.generate(s.root, 0)
+	if err != nil {
+		return "", err
+	}
+	if violations := s.Validate(generated); len(violations) != 0 {
+		return "", fmt.Errorf("failed to generate a conforming document: %w", &SchemaValidationError{Violations: violations})
+	}
+	result, err := json.Marshal(generated)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// intn picks a number in [0, n): the first for minimal generation, the last for maximal generation
+func (g *generator) intn(n int) int {
+	switch g.mode {
+	case generateMinimal:
+		return 0
+	case generateMaximal:
+		return n - 1
+	default:
+		return g.random.Intn(n)
+	}
+}
+
+// between picks a number in [low, high]
+func (g *generator) between(low, high int) int {
+	if high <= low {
+		return low
+	}
+	return low + g.intn(high-low+1)
+}
+
+func (g *generator) generate(schema interface{}, depth int) (interface{}, error) {
+	if depth > maxSchemaDepth {
+		return nil, errors.New("cannot generate a value: schema is too deeply nested, it probably has a reference cycle")
+	}
+	switch schema := schema.(type) {
+	case bool:
+		if !schema {
+			return nil, errors.New("cannot generate a value for schema false")
+		}
+		return nil, nil
+	case map[string]interface{}:
+		return g.generateFromObject(schema, depth)
+	default:
+		return nil, nil
+	}
+}
+
+func (g *generator) generateFromObject(schema map[string]interface{}, depth int) (interface{}, error) {
+	schema, err := g.flatten(schema, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	if constant, ok := schema["const"]; ok {
+		return constant, nil
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) != 0 {
+		return enum[g.intn(len(enum))], nil
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok && len(oneOf) != 0 {
+		return g.generateAlternative(schema, "oneOf", oneOf, depth)
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok && len(anyOf) != 0 {
+		return g.generateAlternative(schema, "anyOf", anyOf, depth)
+	}
+
+	switch g.pickType(schema) {
+	case "object":
+		return g.generateObject(schema, depth)
+	case "array":
+		return g.generateArray(schema, depth)
+	case "string":
+		return g.generateString(schema)
+	case "integer":
+		return g.generateNumber(schema, true), nil
+	case "number":
+		return g.generateNumber(schema, false), nil
+	case "boolean":
+		return g.intn(2) == 1, nil
+	default:
+		return nil, nil
+	}
+}
+
+// flatten resolves $ref and merges allOf into a single schema
+func (g *generator) flatten(schema map[string]interface{}, depth int) (map[string]interface{}, error) {
+	if depth > maxSchemaDepth {
+		return nil, errors.New("cannot generate a value: schema is too deeply nested, it probably has a reference cycle")
+	}
+	reference, hasReference := schema["$ref"].(string)
+	allOf, hasAllOf := schema["allOf"].([]interface{})
+	if !hasReference && !hasAllOf {
+		return schema, nil
+	}
+
+	flattened := make(map[string]interface{}, len(schema))
+	var parts []interface{}
+	if hasReference {
+		referenced, err := g.schema.resolve(reference)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, referenced)
+	}
+	parts = append(parts, allOf...)
+	for _, part := range parts {
+		partSchema, ok := part.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		partSchema, err := g.flatten(partSchema, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		mergeSchemas(flattened, partSchema)
+	}
+	for keyword, value := range schema {
+		if keyword != "$ref" && keyword != "allOf" {
+			mergeSchemas(flattened, map[string]interface{}{keyword: value})
+		}
+	}
+	return flattened, nil
+}
+
+// mergeSchemas merges the keywords of source into target, combining properties and required properties
+func mergeSchemas(target, source map[string]interface{}) {
+	for keyword, value := range source {
+		switch keyword {
+		case "properties":
+			properties, _ := target[keyword].(map[string]interface{})
+			merged := make(map[string]interface{}, len(properties))
+			for attribute, propertySchema := range properties {
+				merged[attribute] = propertySchema
+			}
+			if sourceProperties, ok := value.(map[string]interface{}); ok {
+				for attribute, propertySchema := range sourceProperties {
+					merged[attribute] = propertySchema
+				}
+			}
+			target[keyword] = merged
+		case "required":
+			required, _ := target[keyword].([]interface{})
+			merged := append([]interface{}{}, required...)
+			if sourceRequired, ok := value.([]interface{}); ok {
+				merged = append(merged, sourceRequired...)
+			}
+			target[keyword] = merged
+		default:
+			target[keyword] = value
+		}
+	}
+}
+
+// generateAlternative generates a value from one of the alternatives, trying them until the value is valid
+func (g *generator) generateAlternative(schema map[string]interface{}, keyword string, alternatives []interface{}, depth int) (interface{}, error) {
+	first := g.intn(len(alternatives))
+	var lastErr error
+	for i := range alternatives {
+		alternative := alternatives[(first+i)%len(alternatives)]
+		combined := make(map[string]interface{}, len(schema))
+		for schemaKeyword, value := range schema {
+			if schemaKeyword != keyword {
+				combined[schemaKeyword] = value
+			}
+		}
+		if alternativeSchema, ok := alternative.(map[string]interface{}); ok {
+			mergeSchemas(combined, map[string]interface{}{"allOf": []interface{}{alternativeSchema}})
+		}
+		generated, err := g.generate(combined, depth+1)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		matches := g.schema.countMatches(alternatives, generated, "", depth)
+		if (keyword == "oneOf" && matches == 1) || (keyword == "anyOf" && matches != 0) {
+			return generated, nil
+		}
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("cannot generate a value matching %s", keyword)
+}
+
+func (g *generator) pickType(schema map[string]interface{}) string {
+	switch schemaType := schema["type"].(type) {
+	case string:
+		return schemaType
+	case []interface{}:
+		if len(schemaType) != 0 {
+			if picked, ok := schemaType[g.intn(len(schemaType))].(string); ok {
+				return picked
+			}
+		}
+	}
+	for _, inference := range typeInferences {
+		if _, ok := schema[inference.keyword]; ok {
+			return inference.inferredType
+		}
+	}
+	return "null"
+}
+
+func (g *generator) generateObject(schema map[string]interface{}, depth int) (interface{}, error) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	attributes := sortedAttributes(properties)
+	required := map[string]bool{}
+	if requiredAttributes, ok := schema["required"].([]interface{}); ok {
+		for _, attribute := range requiredAttributes {
+			if attribute, ok := attribute.(string); ok && !required[attribute] {
+				required[attribute] = true
+				if _, ok := properties[attribute]; !ok {
+					attributes = append(attributes, attribute)
+				}
+			}
+		}
+	}
+	minProperties, _ := schema["minProperties"].(float64)
+
+	object := map[string]interface{}{}
+	for _, attribute := range attributes {
+		include := required[attribute]
+		if !include && depth < maxGenerationDepth {
+			include = g.intn(2) == 1 || float64(len(object)) < minProperties
+		}
+		if !include {
+			continue
+		}
+		propertySchema, ok := properties[attribute]
+		if !ok {
+			propertySchema = additionalPropertiesSchema(schema)
+		}
+		value, err := g.generate(propertySchema, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("cannot generate property %q: %w", attribute, err)
+		}
+		object[attribute] = value
+	}
+	return object, nil
+}
+
+func additionalPropertiesSchema(schema map[string]interface{}) interface{} {
+	if additionalProperties, ok := schema["additionalProperties"]; ok {
+		return additionalProperties
+	}
+	return true
+}
+
+func (g *generator) generateArray(schema map[string]interface{}, depth int) (interface{}, error) {
+	prefixItems, _ := schema["prefixItems"].([]interface{})
+	items, hasItems := schema["items"]
+	minItems, maxItems := 0, maxGeneratedItems
+	if value, ok := schema["minItems"].(float64); ok {
+		minItems = int(value)
+	}
+	if value, ok := schema["maxItems"].(float64); ok && int(value) < maxItems {
+		maxItems = int(value)
+	}
+	if maxItems < minItems {
+		maxItems = minItems
+	}
+	if hasItems && items == false && len(prefixItems) < maxItems {
+		maxItems = len(prefixItems)
+	}
+	if depth >= maxGenerationDepth {
+		maxItems = minItems
+	}
+
+	length := g.between(minItems, maxItems)
+	array := make([]interface{}, 0, length)
+	for i := 0; i < length; i++ {
+		var itemSchema interface{} = true
+		if i < len(prefixItems) {
+			itemSchema = prefixItems[i]
+		} else if hasItems {
+			itemSchema = items
+		} else if contains, ok := schema["contains"]; ok {
+			itemSchema = contains
+		}
+		item, err := g.generate(itemSchema, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("cannot generate item %d: %w", i, err)
+		}
+		array = append(array, item)
+	}
+	return array, nil
+}
+
+func (g *generator) generateString(schema map[string]interface{}) (interface{}, error) {
+	if format, ok := schema["format"].(string); ok {
+		if example, ok := formatExamples[format]; ok {
+			return example, nil
+		}
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		return g.generateFromPattern(pattern)
+	}
+
+	minLength, maxLength := 0, maxGeneratedStringLength
+	if value, ok := schema["minLength"].(float64); ok {
+		minLength = int(value)
+	}
+	if value, ok := schema["maxLength"].(float64); ok && int(value) < maxLength {
+		maxLength = int(value)
+	}
+	if maxLength < minLength {
+		maxLength = minLength
+	}
+
+	length := g.between(minLength, maxLength)
+	var builder strings.Builder
+	for i := 0; i < length; i++ {
+		builder.WriteByte(byte('a' + g.intn(26)))
+	}
+	return builder.String(), nil
+}
+
+func (g *generator) generateFromPattern(pattern string) (string, error) {
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", fmt.Errorf("cannot generate a string matching pattern [%q]: %w", pattern, err)
+	}
+	var builder strings.Builder
+	g.writePatternMatch(&builder, parsed.Simplify())
+	return builder.String(), nil
+}
+
+// writePatternMatch writes a string matching the regular expression
+func (g *generator) writePatternMatch(builder *strings.Builder, parsed *syntax.Regexp) {
+	switch parsed.Op {
+	case syntax.OpLiteral:
+		for _, r := range parsed.Rune {
+			builder.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		builder.WriteRune(g.pickRune(parsed.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		builder.WriteByte(byte('a' + g.intn(26)))
+	case syntax.OpCapture:
+		g.writePatternMatch(builder, parsed.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range parsed.Sub {
+			g.writePatternMatch(builder, sub)
+		}
+	case syntax.OpAlternate:
+		g.writePatternMatch(builder, parsed.Sub[g.intn(len(parsed.Sub))])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		minRepetitions, maxRepetitions := 0, maxGeneratedRepetitions
+		switch parsed.Op {
+		case syntax.OpPlus:
+			minRepetitions = 1
+		case syntax.OpQuest:
+			maxRepetitions = 1
+		case syntax.OpRepeat:
+			minRepetitions, maxRepetitions = parsed.Min, parsed.Max
+			if maxRepetitions < 0 {
+				maxRepetitions = minRepetitions + maxGeneratedRepetitions
+			}
+		}
+		for i := g.between(minRepetitions, maxRepetitions); i > 0; i-- {
+			g.writePatternMatch(builder, parsed.Sub[0])
+		}
+	}
+}
+
+// pickRune picks a rune in a character class, given as pairs of inclusive bounds, preferring printable ASCII
+func (g *generator) pickRune(ranges []rune) rune {
+	var printable []rune
+	for i := 0; i+1 < len(ranges); i += 2 {
+		low, high := ranges[i], ranges[i+1]
+		if low < '!' {
+			low = '!'
+		}
+		if high > '~' {
+			high = '~'
+		}
+		if low <= high {
+			printable = append(printable, low, high)
+		}
+	}
+	if len(printable) == 0 {
+		if len(ranges) == 0 {
+			return utf8.RuneError
+		}
+		printable = ranges
+	}
+	pair := 2 * g.intn(len(printable)/2)
+	return printable[pair] + rune(g.intn(int(printable[pair+1]-printable[pair])+1))
+}
+
+func (g *generator) generateNumber(schema map[string]interface{}, integer bool) float64 {
+	step := 0.0
+	if multipleOf, ok := schema["multipleOf"].(float64); ok && multipleOf > 0 {
+		step = multipleOf
+	}
+	if integer {
+		step = integerStep(step)
+	}
+
+	low, high := math.Inf(-1), math.Inf(1)
+	if minimum, ok := schema["minimum"].(float64); ok {
+		low = minimum
+	}
+	if minimum, ok := schema["exclusiveMinimum"].(float64); ok && minimum >= low {
+		low = math.Nextafter(minimum, math.Inf(1))
+	}
+	if maximum, ok := schema["maximum"].(float64); ok {
+		high = maximum
+	}
+	if maximum, ok := schema["exclusiveMaximum"].(float64); ok && maximum <= high {
+		high = math.Nextafter(maximum, math.Inf(-1))
+	}
+	switch {
+	case math.IsInf(low, -1) && math.IsInf(high, 1):
+		low, high = 0, generatedNumberRange
+	case math.IsInf(low, -1):
+		low = math.Min(0, high-generatedNumberRange)
+	case math.IsInf(high, 1):
+		high = low + generatedNumberRange
+	}
+	if step != 0 {
+		low, high = math.Ceil(low/step)*step, math.Floor(high/step)*step
+	}
+	if high <= low {
+		return low
+	}
+	if g.mode == generateMinimal && low <= 0 && 0 <= high {
+		return 0
+	}
+
+	if step != 0 {
+		return low + float64(g.between(0, int(math.Min((high-low)/step, math.MaxInt32))))*step
+	}
+	switch g.mode {
+	case generateMinimal:
+		return low
+	case generateMaximal:
+		return high
+	default:
+		return low + g.random.Float64()*(high-low)
+	}
+}
+
+// integerStep returns the smallest positive integer that is a multiple of step, or 1 if step is 0
+func integerStep(step float64) float64 {
+	if step == 0 {
+		return 1
+	}
+	for factor := 1.0; factor <= 100; factor++ {
+		if multiple := step * factor; multiple == math.Trunc(multiple) {
+			return multiple
+		}
+	}
+	return math.Ceil(step)
+}
@@ -0,0 +1,164 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const questSchema = `{
+	"type": "object",
+	"required": ["id", "name", "status", "knights", "reward"],
+	"properties": {
+		"id": {"type": "string", "format": "uuid"},
+		"name": {"type": "string", "minLength": 3, "maxLength": 10},
+		"code": {"type": "string", "pattern": "^Q-[0-9]{3}-[A-Z]+$"},
+		"status": {"enum": ["planned", "ongoing", "achieved"]},
+		"difficulty": {"type": "integer", "minimum": 1, "maximum": 5},
+		"startedAt": {"type": "string", "format": "date-time"},
+		"knights": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/knight"}},
+		"reward": {
+			"oneOf": [
+				{"type": "object", "required": ["gold"], "properties": {"gold": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.5}}, "additionalProperties": false},
+				{"type": "object", "required": ["title"], "properties": {"title": {"type": "string"}}, "additionalProperties": false}
+			]
+		},
+		"location": {"allOf": [{"$ref": "#/$defs/place"}, {"required": ["kingdom"]}]}
+	},
+	"additionalProperties": false,
+	"$defs": {
+		"knight": {
+			"type": "object",
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string", "pattern": "^(Perceval|Karadoc|Lancelot)$"},
+				"squire": {"$ref": "#/$defs/knight"}
+			}
+		},
+		"place": {
+			"type": "object",
+			"required": ["name"],
+			"properties": {"name": {"type": "string", "minLength": 1}, "kingdom": {"const": "Logres"}}
+		}
+	}
+}`
+
+func TestGenerateMinimal(t *testing.T) {
+	schema, err := ParseSchema(questSchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	generated, err := schema.GenerateMinimal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{
+		"id": "123e4567-e89b-42d3-a456-426614174000",
+		"name": "aaa",
+		"status": "planned",
+		"knights": [{"name": "Perceval"}],
+		"reward": {"gold": 0.5}
+	}`
+	if message, ok := JSONEqual(generated, expected); !ok {
+		t.Error("unexpected document: " + message)
+	}
+}
+
+func TestGenerateMaximal(t *testing.T) {
+	schema, err := ParseSchema(questSchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	generated, err := schema.GenerateMaximal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, path := range []string{"code", "difficulty", "startedAt", "knights[4].squire.name", "location.kingdom"} {
+		if holds, _ := Exists(path)(mustParse(t, generated)); !holds {
+			t.Errorf("expected maximal document to have an element at path [%v]: %v", path, generated)
+		}
+	}
+}
+
+func TestGenerateRandom(t *testing.T) {
+	schema, err := ParseSchema(questSchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	distinctDocuments := map[string]bool{}
+	for seed := int64(0); seed < 50; seed++ {
+		generated, err := schema.GenerateRandom(seed)
+		if err != nil {
+			t.Fatalf("seed %d: unexpected error: %v", seed, err)
+		}
+		again, _ := schema.GenerateRandom(seed)
+		if generated != again {
+			t.Errorf("seed %d generated different documents: [%v] and [%v]", seed, generated, again)
+		}
+		distinctDocuments[generated] = true
+	}
+	if len(distinctDocuments) < 40 {
+		t.Errorf("expected random documents to differ, got only %d distinct documents", len(distinctDocuments))
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := map[string]struct {
+		schema        string
+		expectedError error
+	}{
+		"false schema": {
+			schema:        `{"properties": {"never": false}, "required": ["never"]}`,
+			expectedError: errors.New(`cannot generate property "never": cannot generate a value for schema false`),
+		},
+		"unsupported constraint": {
+			schema: `{"type": "string", "not": {"type": "string"}}`,
+			expectedError: errors.New(`failed to generate a conforming document: document does not conform to schema:
+- (root): matches the schema of not`),
+		},
+		"impossible alternative": {
+			schema:        `{"oneOf": [{"type": "string"}, {"type": "string"}]}`,
+			expectedError: errors.New("cannot generate a value matching oneOf"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			schema, err := ParseSchema(test.schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_, err = schema.GenerateMinimal()
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestGenerateThenRefine(t *testing.T) {
+	schema, err := ParseSchema(questSchema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	generated, err := schema.GenerateMinimal()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	refined, err := Modify(generated, Set("name", "Graal"), Set("knights[1].name", "Karadoc"), ValidateSchema(questSchema))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if holds, _ := Equals("knights[1].name", "Karadoc")(mustParse(t, refined)); !holds {
+		t.Errorf("unexpected refined document: %v", refined)
+	}
+}
+
+func mustParse(t *testing.T, document string) interface{} {
+	t.Helper()
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(document), &parsed); err != nil {
+		t.Fatalf("failed to parse document: %v", err)
+	}
+	return parsed
+}
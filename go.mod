@@ -3,5 +3,8 @@ module github.com/remieven/slowjsonmutator-go
 go 1.16
 
 require (
+	github.com/fxamacker/cbor/v2 v2.5.0
 	github.com/go-test/deep v1.0.7
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	gopkg.in/yaml.v3 v3.0.1
 )
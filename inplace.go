@@ -0,0 +1,471 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// editsCapacity is the number of modifications made by SetPath and RemovePath that Modify recognizes
+const editsCapacity = 256
+
+// edit is a Set or a Remove that Modify can apply directly to the bytes of a document
+type edit struct {
+	segments []jsonPathSegment
+	value    interface{}
+	remove   bool
+}
+
+// recentEdits holds the edits of the latest modifications made by SetPath and RemovePath
+var recentEdits = &editRegistry{edits: make(map[unsafe.Pointer]edit, editsCapacity)}
+
+// editRegistry maps modifications to the edits they make, safe for concurrent use.
+// Func values cannot be compared, so modifications are identified by their closure, which the registry keeps alive
+// so that its address is not reused by another one. Only the latest modifications are kept: older ones are applied
+// to the decoded document, as any other modification.
+type editRegistry struct {
+	mutex sync.Mutex
+	edits map[unsafe.Pointer]edit
+	// order holds the closures of the registered modifications as a ring, next being the oldest once it is full
+	order []unsafe.Pointer
+	next  int
+}
+
+// closure returns the address of the closure of a modification
+func closure(modification JSONModification) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&modification))
+}
+
+func (r *editRegistry) register(modification JSONModification, e edit) {
+	key := closure(modification)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.order) < editsCapacity {
+		r.order = append(r.order, key)
+	} else {
+		delete(r.edits, r.order[r.next])
+		r.order[r.next] = key
+		r.next = (r.next + 1) % editsCapacity
+	}
+	r.edits[key] = e
+}
+
+// lookup returns the edits made by modifications, or false if one of them is not a registered Set or Remove
+func (r *editRegistry) lookup(modifications []JSONModification) ([]edit, bool) {
+	edits := make([]edit, 0, len(modifications))
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, modification := range modifications {
+		e, ok := r.edits[closure(modification)]
+		if !ok {
+			return nil, false
+		}
+		edits = append(edits, e)
+	}
+	return edits, true
+}
+
+// elementSpan locates a member of an object or an element of an array in raw JSON
+type elementSpan struct {
+	// start is the start of the key for object members, and of the value for array elements
+	start      int
+	valueStart int
+	end        int
+}
+
+// containerScan is what scanContainer finds in an object or an array
+type containerScan struct {
+	// target is the element addressed by the path segment, if found
+	target elementSpan
+	found  bool
+	// count is the number of elements scanned, which is all of them when the target is not found in an array
+	count int
+	// previousEnd is the end of the element before the target, or before where it would be inserted, or -1.
+	// nextStart is the start of the element after the target, or after where it would be inserted, or -1.
+	previousEnd, nextStart int
+}
+
+// modifyInPlace applies edits to the bytes of input, when input is written exactly as Modify would write it,
+// so that the output is the same as that of decoding, modifying and encoding the document.
+// It returns false when a modification is not a Set or a Remove, when input is written otherwise,
+// or when one of the edits would fail.
+func modifyInPlace(input string, modifications []JSONModification) (string, bool) {
+	if len(modifications) == 0 {
+		return "", false
+	}
+	edits, ok := recentEdits.lookup(modifications)
+	if !ok || !isCanonical(input) {
+		return "", false
+	}
+
+	data := input
+	for _, e := range edits {
+		if data, ok = e.apply(data); !ok {
+			return "", false
+		}
+	}
+	return data, true
+}
+
+// apply applies the edit to canonical JSON, keeping it canonical, or returns false if it cannot be done without decoding
+func (e edit) apply(data string) (string, bool) {
+	start := 0
+	for depth, segment := range e.segments {
+		switch {
+		case data[start] == '{' && segment.attribute != nil && isPlainString(*segment.attribute):
+		case data[start] == '[' && segment.index != nil && *segment.index >= 0:
+		case data[start] == 'n' && e.remove:
+			return data, true
+		case data[start] == 'n':
+			created, ok := e.encodeCreated(e.segments[depth:])
+			if !ok {
+				return "", false
+			}
+			return splice(data, start, start+len("null"), created), true
+		default:
+			return "", false
+		}
+
+		scan := scanContainer(data, start, segment, depth == len(e.segments)-1)
+		switch {
+		case !scan.found && e.remove:
+			return data, true
+		case !scan.found:
+			if segment.index != nil && *segment.index > scan.count {
+				return "", false
+			}
+			created, ok := e.encodeCreated(e.segments[depth+1:])
+			if !ok {
+				return "", false
+			}
+			if segment.attribute != nil {
+				created = append([]byte(`"`+*segment.attribute+`":`), created...)
+			}
+			switch {
+			case scan.nextStart != -1:
+				return splice(data, scan.nextStart, scan.nextStart, append(created, ',')), true
+			case scan.previousEnd != -1:
+				return splice(data, scan.previousEnd, scan.previousEnd, append([]byte{','}, created...)), true
+			default:
+				return splice(data, start+1, start+1, created), true
+			}
+		case depth == len(e.segments)-1 && e.remove:
+			switch {
+			case scan.nextStart != -1:
+				return splice(data, scan.target.start, scan.nextStart, nil), true
+			case scan.previousEnd != -1:
+				return splice(data, scan.previousEnd, scan.target.end, nil), true
+			default:
+				return splice(data, scan.target.start, scan.target.end, nil), true
+			}
+		case depth == len(e.segments)-1:
+			encoded, ok := e.encodeCreated(nil)
+			if !ok {
+				return "", false
+			}
+			return splice(data, scan.target.valueStart, scan.target.end, encoded), true
+		default:
+			start = scan.target.valueStart
+		}
+	}
+	return "", false
+}
+
+// encodeCreated returns the JSON encoding of what Set creates in place of a missing or null element,
+// given the remaining path segments
+func (e edit) encodeCreated(segments []jsonPathSegment) ([]byte, bool) {
+	value, err := normalizeValue(e.value)
+	if err != nil {
+		return nil, false
+	}
+	if len(segments) != 0 {
+		if value, err = set(nil, segments, value); err != nil {
+			return nil, false
+		}
+	}
+	encoded, err := json.Marshal(value)
+	return encoded, err == nil
+}
+
+// scanContainer looks for the element addressed by segment in the canonical object or array starting at start.
+// It stops at the element following the target, or at the first key sorted after the addressed one.
+// Unless last is true, it stops at the start of the target value, which is all that is needed to go deeper.
+func scanContainer(data string, start int, segment jsonPathSegment, last bool) containerScan {
+	scan := containerScan{previousEnd: -1, nextStart: -1}
+	for i := start + 1; data[i] != '}' && data[i] != ']'; {
+		span := elementSpan{start: i, valueStart: i}
+		var order int
+		if segment.attribute != nil {
+			keyEnd := stringEnd(data, i)
+			order = strings.Compare(data[i+1:keyEnd-1], *segment.attribute)
+			span.valueStart = keyEnd + 1
+		} else {
+			order = compareInts(scan.count, *segment.index)
+		}
+		if order == 0 && !last {
+			scan.target, scan.found = span, true
+			return scan
+		}
+		if order > 0 {
+			scan.nextStart = i
+			return scan
+		}
+
+		span.end = valueEnd(data, span.valueStart)
+		if order == 0 {
+			scan.target, scan.found = span, true
+		} else {
+			scan.previousEnd = span.end
+		}
+		scan.count++
+
+		i = span.end
+		if data[i] == ',' {
+			i++
+		}
+	}
+	return scan
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// splice returns data where the bytes between from and to are replaced by replacement
+func splice(data string, from, to int, replacement []byte) string {
+	var builder strings.Builder
+	builder.Grow(len(data) - (to - from) + len(replacement))
+	builder.WriteString(data[:from])
+	builder.Write(replacement)
+	builder.WriteString(data[to:])
+	return builder.String()
+}
+
+func skipWhitespace(data string, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// stringEnd returns the index following the closing quote of the string starting at i
+func stringEnd(data string, i int) int {
+	for i++; data[i] != '"'; i++ {
+		if data[i] == '\\' {
+			i++
+		}
+	}
+	return i + 1
+}
+
+// valueEnd returns the index following the end of the valid JSON value starting at i
+func valueEnd(data string, i int) int {
+	switch data[i] {
+	case '"':
+		return stringEnd(data, i)
+	case '{', '[':
+		depth := 0
+		for {
+			switch data[i] {
+			case '"':
+				i = stringEnd(data, i)
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			i++
+		}
+	default:
+		for i < len(data) && strings.IndexByte(",}] \t\n\r", data[i]) == -1 {
+			i++
+		}
+		return i
+	}
+}
+
+// isCanonical tells whether data is written exactly as Modify writes the document it holds: compact, with sorted
+// and unique object keys that need no escaping, and with strings and numbers encoded as encoding/json encodes them
+func isCanonical(data string) bool {
+	checker := &canonicalChecker{jsonDecoder{input: data}}
+	return checker.value() && checker.position == len(data)
+}
+
+// canonicalChecker reads JSON as jsonDecoder does, checking that it is canonical instead of decoding it
+type canonicalChecker struct {
+	jsonDecoder
+}
+
+func (c *canonicalChecker) value() bool {
+	if c.position == len(c.input) {
+		return false
+	}
+	switch c.input[c.position] {
+	case '{':
+		return c.object()
+	case '[':
+		return c.array()
+	case '"':
+		_, ok := c.string()
+		return ok
+	case 't':
+		return c.literal("true")
+	case 'f':
+		return c.literal("false")
+	case 'n':
+		return c.literal("null")
+	default:
+		start := c.position
+		number, ok := c.float()
+		return ok && isCanonicalNumber(c.input[start:c.position], number)
+	}
+}
+
+func (c *canonicalChecker) object() bool {
+	if c.depth++; c.depth > maxDecodingDepth {
+		return false
+	}
+	defer func() { c.depth-- }()
+
+	c.position++
+	if c.position < len(c.input) && c.input[c.position] == '}' {
+		c.position++
+		return true
+	}
+	previous, first := "", true
+	for {
+		if c.position == len(c.input) || c.input[c.position] != '"' {
+			return false
+		}
+		key, ok := c.string()
+		if !ok || strings.IndexByte(key, '\\') != -1 || (!first && key <= previous) {
+			return false
+		}
+		previous, first = key, false
+		if c.position == len(c.input) || c.input[c.position] != ':' {
+			return false
+		}
+		c.position++
+		if !c.value() || c.position == len(c.input) {
+			return false
+		}
+		c.position++
+		switch c.input[c.position-1] {
+		case ',':
+		case '}':
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+func (c *canonicalChecker) array() bool {
+	if c.depth++; c.depth > maxDecodingDepth {
+		return false
+	}
+	defer func() { c.depth-- }()
+
+	c.position++
+	if c.position < len(c.input) && c.input[c.position] == ']' {
+		c.position++
+		return true
+	}
+	for {
+		if !c.value() || c.position == len(c.input) {
+			return false
+		}
+		c.position++
+		switch c.input[c.position-1] {
+		case ',':
+		case ']':
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// string checks the string starting at the current position, which may only use the escape sequences
+// of encoding/json that do not depend on its version, and returns its raw content
+func (c *canonicalChecker) string() (string, bool) {
+	start := c.position + 1
+	for c.position++; c.position < len(c.input); {
+		switch character := c.input[c.position]; {
+		case character == '"':
+			c.position++
+			return c.input[start : c.position-1], true
+		case character == '\\':
+			if c.position+1 == len(c.input) {
+				return "", false
+			}
+			switch c.input[c.position+1] {
+			case '"', '\\', 'n', 'r', 't':
+				c.position += 2
+			case 'u':
+				if len(c.input)-c.position < 6 {
+					return "", false
+				}
+				switch c.input[c.position+2 : c.position+6] {
+				case "003c", "003e", "0026", "2028", "2029":
+					c.position += 6
+				default:
+					return "", false
+				}
+			default:
+				return "", false
+			}
+		case character < 0x20, character == '<', character == '>', character == '&':
+			return "", false
+		case character < utf8.RuneSelf:
+			c.position++
+		default:
+			r, size := utf8.DecodeRuneInString(c.input[c.position:])
+			if (r == utf8.RuneError && size == 1) || r == '\u2028' || r == '\u2029' {
+				return "", false
+			}
+			c.position += size
+		}
+	}
+	return "", false
+}
+
+// isPlainString tells whether encoding/json encodes s without escaping any of its characters
+func isPlainString(s string) bool {
+	checker := &canonicalChecker{jsonDecoder{input: `"` + s + `"`}}
+	content, ok := checker.string()
+	return ok && checker.position == len(checker.input) && strings.IndexByte(content, '\\') == -1
+}
+
+// isCanonicalNumber tells whether raw is number as encoding/json encodes it
+func isCanonicalNumber(raw string, number float64) bool {
+	format := byte('f')
+	if abs := math.Abs(number); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	var buffer [32]byte
+	encoded := strconv.AppendFloat(buffer[:0], number, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(encoded); n >= 4 && encoded[n-4] == 'e' && encoded[n-3] == '-' && encoded[n-2] == '0' {
+			encoded[n-2] = encoded[n-1]
+			encoded = encoded[:n-1]
+		}
+	}
+	return string(encoded) == raw
+}
@@ -0,0 +1,241 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// decodingModify is what Modify does when it cannot apply modifications to the bytes of input
+func decodingModify(input string, modifications []JSONModification) (string, error) {
+	decoded, err := unmarshalString(input)
+	if err != nil {
+		return "", err
+	}
+	decoded, err = applyModifications(decoded, modifications)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(decoded)
+	return string(encoded), err
+}
+
+func TestModifyInPlace(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		modifications  []JSONModification
+		expectedOutput string
+	}{
+		"replace nested value": {
+			input:          `{"manager":{"name":"Arthur"},"name":"Perceval","score":1.5}`,
+			modifications:  []JSONModification{Set("manager.name", "Léodagan")},
+			expectedOutput: `{"manager":{"name":"Léodagan"},"name":"Perceval","score":1.5}`,
+		},
+		"add attributes where they are sorted": {
+			input:          `{"b":1,"d":2}`,
+			modifications:  []JSONModification{Set("c", 3), Set("e", 4), Set("a", 0)},
+			expectedOutput: `{"a":0,"b":1,"c":3,"d":2,"e":4}`,
+		},
+		"add attribute to an empty object": {
+			input:          `{}`,
+			modifications:  []JSONModification{Set("title", "Knight")},
+			expectedOutput: `{"title":"Knight"}`,
+		},
+		"create missing objects and arrays": {
+			input:          `{"name":"Perceval"}`,
+			modifications:  []JSONModification{Set("manager.titles[0].fr", "Suzerain")},
+			expectedOutput: `{"manager":{"titles":[{"fr":"Suzerain"}]},"name":"Perceval"}`,
+		},
+		"replace null by created object": {
+			input:          `{"manager":null}`,
+			modifications:  []JSONModification{Set("manager.name", "Arthur")},
+			expectedOutput: `{"manager":{"name":"Arthur"}}`,
+		},
+		"replace and append array elements": {
+			input:          `[1,[2,3]]`,
+			modifications:  []JSONModification{Set("[1][0]", "two"), SetPath(MustCompilePath("[2]"), map[string]interface{}{"b": 2, "a": "<"})},
+			expectedOutput: `[1,["two",3],{"a":"\u003c","b":2}]`,
+		},
+		"remove first, middle and last attributes": {
+			input:          `{"a":1,"b":{"c":2},"d":3,"e":4}`,
+			modifications:  []JSONModification{Remove("a"), Remove("d"), RemovePath(MustCompilePath("e"))},
+			expectedOutput: `{"b":{"c":2}}`,
+		},
+		"remove only attribute": {
+			input:          `{"a":{"b":1}}`,
+			modifications:  []JSONModification{Remove("a.b")},
+			expectedOutput: `{"a":{}}`,
+		},
+		"remove array elements": {
+			input:          `{"titles":["Knight","Le Gaulois","Provençal"]}`,
+			modifications:  []JSONModification{Remove("titles[1]"), Remove("titles[1]")},
+			expectedOutput: `{"titles":["Knight"]}`,
+		},
+		"remove missing elements": {
+			input:          `{"a":[1],"b":null}`,
+			modifications:  []JSONModification{Remove("c"), Remove("a[3]"), Remove("b.c"), Remove("c.d")},
+			expectedOutput: `{"a":[1],"b":null}`,
+		},
+		"strings and numbers as encoding/json writes them": {
+			input:          `{"a":"\"}\\\n\u003c\u2028","b":[1e+21,1e-7,-0,0.1,123456789]}`,
+			modifications:  []JSONModification{Set("c", "]"), Remove("b[0]")},
+			expectedOutput: `{"a":"\"}\\\n\u003c\u2028","b":[1e-7,-0,0.1,123456789],"c":"]"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, ok := modifyInPlace(test.input, test.modifications)
+			if !ok {
+				t.Fatal("expected the modifications to be applied to the bytes of the input")
+			}
+			if output != test.expectedOutput {
+				t.Errorf("unexpected output: wanted [%v], got [%v]", test.expectedOutput, output)
+			}
+			decodedOutput, err := decodingModify(test.input, test.modifications)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != decodedOutput {
+				t.Errorf("output differs from the decoded modification: wanted [%v], got [%v]", decodedOutput, output)
+			}
+		})
+	}
+}
+
+func TestModifyInPlaceFallback(t *testing.T) {
+	evicted := Set("a", 2)
+	for i := 0; i < editsCapacity; i++ {
+		Remove("a")
+	}
+
+	tests := map[string]struct {
+		input         string
+		modifications []JSONModification
+	}{
+		"whitespace": {
+			input:         `{"a": 1}`,
+			modifications: []JSONModification{Set("a", 2)},
+		},
+		"unsorted attributes": {
+			input:         `{"b":1,"a":2}`,
+			modifications: []JSONModification{Set("c", 3)},
+		},
+		"duplicate keys": {
+			input:         `{"a":1,"a":2}`,
+			modifications: []JSONModification{Set("a", 3)},
+		},
+		"escaped key": {
+			input:         `{"\u003c":1}`,
+			modifications: []JSONModification{Set("a", 2)},
+		},
+		"escape sequences encoding/json does not write": {
+			input:         `["\u00e9","\/"]`,
+			modifications: []JSONModification{Set("[0]", 2)},
+		},
+		"character encoding/json escapes": {
+			input:         `["<"]`,
+			modifications: []JSONModification{Set("[1]", 2)},
+		},
+		"numbers encoding/json writes otherwise": {
+			input:         `[1.50,1E2,100000000000000000000000]`,
+			modifications: []JSONModification{Set("[0]", 2)},
+		},
+		"invalid json": {
+			input:         `{"a":1`,
+			modifications: []JSONModification{Set("a", 2)},
+		},
+		"custom modification": {
+			input: `{"a":1}`,
+			modifications: []JSONModification{Set("b", 2), func(toModify interface{}) (interface{}, error) {
+				return toModify, nil
+			}},
+		},
+		"composed modifications": {
+			input:         `{"a":1}`,
+			modifications: []JSONModification{Chain(Set("a", 2))},
+		},
+		"evicted modification": {
+			input:         `{"a":1}`,
+			modifications: []JSONModification{evicted},
+		},
+		"no modification": {
+			input: `{"a":1}`,
+		},
+		"invalid path": {
+			input:         `{"a":1}`,
+			modifications: []JSONModification{Set("a..b", 2)},
+		},
+		"uncompiled path": {
+			input:         `{"a":1}`,
+			modifications: []JSONModification{SetPath(Path{}, 2)},
+		},
+		"attribute that needs escaping": {
+			input:         `{"a":1}`,
+			modifications: []JSONModification{Set("<", 2)},
+		},
+		"index on an object": {
+			input:         `{"a":1}`,
+			modifications: []JSONModification{Remove("[0]")},
+		},
+		"attribute of a primitive": {
+			input:         `{"a":1}`,
+			modifications: []JSONModification{Set("a.b", 2)},
+		},
+		"out of bounds insertion": {
+			input:         `[1]`,
+			modifications: []JSONModification{Set("[2]", 2)},
+		},
+		"value that cannot be encoded": {
+			input:         `{"a":1}`,
+			modifications: []JSONModification{Set("a", make(chan int))},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if output, ok := modifyInPlace(test.input, test.modifications); ok {
+				t.Errorf("expected the document to be decoded, got [%v]", output)
+			}
+
+			expectedOutput, expectedErr := decodingModify(test.input, test.modifications)
+			output, err := Modify(test.input, test.modifications...)
+			if !ErrorEqual(err, expectedErr) || output != expectedOutput {
+				t.Errorf("unexpected result: wanted [%v] (error: %v), got [%v] (error: %v)", expectedOutput, expectedErr, output, err)
+			}
+		})
+	}
+}
+
+func TestModifyInPlaceMatchesDecoding(t *testing.T) {
+	input := wideDocument(20)
+	paths := []string{"knights[3].name", "knights[3].rank", "knights[19]", "knights[20]", "knights[0].titles[2]", "a", "z.y[0]"}
+	for _, path := range paths {
+		for _, modification := range []JSONModification{Set(path, map[string]interface{}{"x": []interface{}{1.5, "é"}}), Remove(path)} {
+			output, ok := modifyInPlace(input, []JSONModification{modification})
+			expectedOutput, err := decodingModify(input, []JSONModification{modification})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !ok || output != expectedOutput {
+				t.Errorf("unexpected output for [%v]: wanted [%v], got [%v] (applied in place: %v)", path, expectedOutput, output, ok)
+			}
+		}
+	}
+}
+
+func TestIsCanonical(t *testing.T) {
+	var document interface{}
+	if err := json.Unmarshal([]byte(`{"b":[1.5,-2e-9,1e21,"<\u2028\n"],"a":{"é":null,"c":true}}`), &document); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !isCanonical(string(encoded)) {
+		t.Errorf("expected the output of encoding/json to be canonical: %s", encoded)
+	}
+	if isCanonical(string(encoded) + " ") {
+		t.Error("expected trailing whitespace not to be canonical")
+	}
+}
@@ -0,0 +1,180 @@
+package slowjsonmutator
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LineErrorHandling defines what ModifyLinesWithOptions does with a line that cannot be modified
+type LineErrorHandling int
+
+// Line error handlings
+const (
+	// LineErrorFail stops at the first line that cannot be modified, and returns its error
+	LineErrorFail LineErrorHandling = iota
+	// LineErrorSkip leaves out lines that cannot be modified
+	LineErrorSkip
+	// LineErrorAnnotate replaces lines that cannot be modified by a LineError, encoded as JSON
+	LineErrorAnnotate
+)
+
+// LinesOptions configures ModifyLinesWithOptions
+type LinesOptions struct {
+	// OnError is what to do with lines that cannot be modified
+	OnError LineErrorHandling
+	// Workers is the number of lines modified concurrently. Output lines keep the order of input lines.
+	// Zero or one means that lines are modified one after the other.
+	Workers int
+}
+
+// LineError is written in place of a line that cannot be modified, when using LineErrorAnnotate
+type LineError struct {
+	// Line is the number of the line, starting at 1
+	Line int `json:"line"`
+	// Input is the line as it was read
+	Input string `json:"input"`
+	// Error is the reason why the line could not be modified
+	Error string `json:"error"`
+}
+
+// ModifyLines applies modifications to each line of newline-delimited JSON read from r, and writes the results to w.
+// It stops at the first line that cannot be modified.
+func ModifyLines(r io.Reader, w io.Writer, modifications ...JSONModification) error {
+	return ModifyLinesWithOptions(r, w, LinesOptions{}, modifications...)
+}
+
+// ModifyLinesWithOptions applies modifications to each line of newline-delimited JSON read from r, and writes the results to w,
+// one per line and in the order of input lines. Blank lines are left out.
+// With several workers, lines following one that makes it fail may have been read from r already.
+func ModifyLinesWithOptions(r io.Reader, w io.Writer, options LinesOptions, modifications ...JSONModification) error {
+	reader, writer := bufio.NewReader(r), bufio.NewWriter(w)
+	var err error
+	if options.Workers <= 1 {
+		err = modifyLinesSequentially(reader, writer, options, modifications)
+	} else {
+		err = modifyLinesConcurrently(reader, writer, options, modifications)
+	}
+	if flushErr := writer.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+func modifyLinesSequentially(reader *bufio.Reader, writer *bufio.Writer, options LinesOptions, modifications []JSONModification) error {
+	for number := 1; ; number++ {
+		line, readErr := reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("failed to read line %d: %w", number, readErr)
+		}
+		output, err := modifyLine(number, line, options.OnError, modifications)
+		if err != nil {
+			return err
+		}
+		if _, err := writer.WriteString(output); err != nil {
+			return err
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+// lineJob is a line to be modified by one of the workers of modifyLinesConcurrently
+type lineJob struct {
+	number int
+	line   string
+	// readErr is set instead of line when reading failed
+	readErr error
+	result  chan lineResult
+}
+
+type lineResult struct {
+	output string
+	err    error
+}
+
+// modifyLinesConcurrently reads lines in a goroutine, and hands them both to workers and to the writing loop,
+// which waits for the results in the order of lines
+func modifyLinesConcurrently(reader *bufio.Reader, writer *bufio.Writer, options LinesOptions, modifications []JSONModification) error {
+	jobs := make(chan *lineJob, options.Workers)
+	ordered := make(chan *lineJob, 2*options.Workers)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(jobs)
+		defer close(ordered)
+		for number := 1; ; number++ {
+			line, err := reader.ReadString('\n')
+			job := &lineJob{number: number, line: line, result: make(chan lineResult, 1)}
+			if err != nil && err != io.EOF {
+				job.readErr = fmt.Errorf("failed to read line %d: %w", number, err)
+				job.result <- lineResult{err: job.readErr}
+			}
+			select {
+			case ordered <- job:
+			case <-done:
+				return
+			}
+			if job.readErr != nil {
+				return
+			}
+			select {
+			case jobs <- job:
+			case <-done:
+				return
+			}
+			if err == io.EOF {
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < options.Workers; i++ {
+		go func() {
+			for job := range jobs {
+				output, err := modifyLine(job.number, job.line, options.OnError, modifications)
+				job.result <- lineResult{output: output, err: err}
+			}
+		}()
+	}
+
+	for job := range ordered {
+		result := <-job.result
+		if result.err != nil {
+			return result.err
+		}
+		if _, err := writer.WriteString(result.output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// modifyLine returns what should be written for a line, including its line feed, or an empty string if nothing should be
+func modifyLine(number int, line string, onError LineErrorHandling, modifications []JSONModification) (string, error) {
+	trimmed := strings.TrimRight(line, "\r\n")
+	if strings.TrimSpace(trimmed) == "" {
+		return "", nil
+	}
+	output, err := Modify(trimmed, modifications...)
+	if err == nil {
+		return output + "\n", nil
+	}
+
+	switch onError {
+	case LineErrorSkip:
+		return "", nil
+	case LineErrorAnnotate:
+		annotation, err := json.Marshal(LineError{Line: number, Input: trimmed, Error: err.Error()})
+		if err != nil {
+			return "", err
+		}
+		return string(annotation) + "\n", nil
+	default:
+		return "", fmt.Errorf("cannot modify line %d: %w", number, err)
+	}
+}
@@ -0,0 +1,110 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModifyLines(t *testing.T) {
+	input := "{\"name\": \"Perceval\"}\n\n[\"Karadoc\"]\r\n{\"name\": \"Arthur\"\n{\"name\": \"Léodagan\"}"
+	tests := map[string]struct {
+		onError        LineErrorHandling
+		expectedOutput string
+		expectedError  error
+	}{
+		"fail": {
+			onError:        LineErrorFail,
+			expectedOutput: "{\"name\":\"Perceval\",\"title\":\"Knight\"}\n",
+			expectedError:  errors.New("cannot modify line 3: cannot address content of JSON array by attribute"),
+		},
+		"skip": {
+			onError:        LineErrorSkip,
+			expectedOutput: "{\"name\":\"Perceval\",\"title\":\"Knight\"}\n{\"name\":\"Léodagan\",\"title\":\"Knight\"}\n",
+		},
+		"annotate": {
+			onError: LineErrorAnnotate,
+			expectedOutput: "{\"name\":\"Perceval\",\"title\":\"Knight\"}\n" +
+				"{\"line\":3,\"input\":\"[\\\"Karadoc\\\"]\",\"error\":\"cannot address content of JSON array by attribute\"}\n" +
+				"{\"line\":4,\"input\":\"{\\\"name\\\": \\\"Arthur\\\"\",\"error\":\"unexpected end of JSON input\"}\n" +
+				"{\"name\":\"Léodagan\",\"title\":\"Knight\"}\n",
+		},
+	}
+
+	for name, test := range tests {
+		for _, workers := range []int{0, 4} {
+			t.Run(name+" with "+strconv.Itoa(workers)+" workers", func(t *testing.T) {
+				var output strings.Builder
+				err := ModifyLinesWithOptions(strings.NewReader(input), &output, LinesOptions{OnError: test.onError, Workers: workers}, Set("title", "Knight"))
+				if !ErrorEqual(err, test.expectedError) {
+					t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				}
+				if output.String() != test.expectedOutput {
+					t.Errorf("unexpected output: wanted [%v], got [%v]", test.expectedOutput, output.String())
+				}
+			})
+		}
+	}
+}
+
+func TestModifyLinesKeepsOrderWithWorkers(t *testing.T) {
+	const size = 500
+	var input strings.Builder
+	for i := 0; i < size; i++ {
+		input.WriteString(`{"id":` + strconv.Itoa(i) + "}\n")
+	}
+	// lines take a varying time to be modified, so that workers finish them out of order
+	slow := func(toModify interface{}) (interface{}, error) {
+		id := toModify.(map[string]interface{})["id"].(float64)
+		time.Sleep(time.Duration(int(id)%7) * 10 * time.Microsecond)
+		return Set("even", int(id)%2 == 0)(toModify)
+	}
+
+	var output strings.Builder
+	if err := ModifyLinesWithOptions(strings.NewReader(input.String()), &output, LinesOptions{Workers: 8}, slow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) != size {
+		t.Fatalf("unexpected number of lines: wanted [%v], got [%v]", size, len(lines))
+	}
+	for i, line := range lines {
+		expected := `{"even":` + strconv.FormatBool(i%2 == 0) + `,"id":` + strconv.Itoa(i) + `}`
+		if line != expected {
+			t.Fatalf("unexpected line %d: wanted [%v], got [%v]", i+1, expected, line)
+		}
+	}
+}
+
+func TestModifyLinesReadError(t *testing.T) {
+	for _, workers := range []int{0, 4} {
+		t.Run(strconv.Itoa(workers)+" workers", func(t *testing.T) {
+			reader := &failingReader{content: "{\"name\": \"Perceval\"}\n{\"name\""}
+			var output strings.Builder
+			err := ModifyLinesWithOptions(reader, &output, LinesOptions{Workers: workers})
+			expectedError := errors.New("failed to read line 2: broken pipe")
+			if !ErrorEqual(err, expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", expectedError, err)
+			}
+			if expected := "{\"name\":\"Perceval\"}\n"; output.String() != expected {
+				t.Errorf("unexpected output: wanted [%v], got [%v]", expected, output.String())
+			}
+		})
+	}
+}
+
+// failingReader returns its content, then fails
+type failingReader struct {
+	content string
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.content == "" {
+		return 0, errors.New("broken pipe")
+	}
+	n := copy(p, r.content)
+	r.content = r.content[n:]
+	return n, nil
+}
@@ -1,6 +1,7 @@
 package slowjsonmutator
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,18 +10,20 @@ import (
 	"strings"
 )
 
-// JSONModification is a function that can modify parsed untyped json data
+// JSONModification is a function that can modify parsed untyped json data.
+// The modifications of this package never mutate their input: they return a modified copy,
+// which shares with the input the elements they left untouched.
 type JSONModification func(interface{}) (interface{}, error)
 
-// Remove removes the element at the given path
+// Remove removes the element at the given path.
+// The path is parsed when the modification is built; use RemovePath with a compiled path
+// to get invalid paths reported at that time rather than when the modification is applied.
 func Remove(path string) JSONModification {
-	return func(toModify interface{}) (interface{}, error) {
-		pathSegments, err := parseJSONPath(path)
-		if err != nil {
-			return nil, err
-		}
-		return remove(toModify, pathSegments)
+	compiled, err := compiledPaths.compile(path)
+	if err != nil {
+		return failingModification(err)
 	}
+	return RemovePath(compiled)
 }
 
 type jsonPathSegment struct {
@@ -46,22 +49,24 @@ func remove(toModify interface{}, parsedPath []jsonPathSegment) (interface{}, er
 		if parsedPath[0].attribute == nil {
 			return nil, errors.New("cannot address content of JSON object by index")
 		}
-		if len(parsedPath) == 1 {
-			delete(toModify, *parsedPath[0].attribute)
-			return toModify, nil
-		}
-
 		deeper, ok := toModify[*parsedPath[0].attribute]
 		if !ok {
 			return toModify, nil
 		}
 
+		if len(parsedPath) == 1 {
+			modified := copyObject(toModify, 0)
+			delete(modified, *parsedPath[0].attribute)
+			return modified, nil
+		}
+
 		modifiedDeeper, err := remove(deeper, parsedPath[1:])
 		if err != nil {
 			return nil, err
 		}
-		toModify[*parsedPath[0].attribute] = modifiedDeeper
-		return toModify, nil
+		modified := copyObject(toModify, 0)
+		modified[*parsedPath[0].attribute] = modifiedDeeper
+		return modified, nil
 	case []interface{}:
 		if parsedPath[0].index == nil {
 			return nil, errors.New("cannot address content of JSON array by attribute")
@@ -80,8 +85,9 @@ func remove(toModify interface{}, parsedPath []jsonPathSegment) (interface{}, er
 		if err != nil {
 			return nil, err
 		}
-		toModify[index] = modifiedDeeper
-		return toModify, nil
+		modified := copyArray(toModify, 0)
+		modified[index] = modifiedDeeper
+		return modified, nil
 	case nil:
 		return toModify, nil
 	default:
@@ -89,26 +95,87 @@ func remove(toModify interface{}, parsedPath []jsonPathSegment) (interface{}, er
 	}
 }
 
+// removeFromSlice returns a copy of slice without the element at index
 func removeFromSlice(slice []interface{}, index int) []interface{} {
-	switch {
-	case index < 0 || len(slice) <= index:
+	if index < 0 || len(slice) <= index {
 		return slice
-	case index == len(slice)-1:
-		return slice[:len(slice)-1]
-	default:
-		return append(slice[:index], slice[index+1:]...)
 	}
+	removed := make([]interface{}, 0, len(slice)-1)
+	removed = append(removed, slice[:index]...)
+	return append(removed, slice[index+1:]...)
+}
+
+// copyObject returns a shallow copy of object, with room for extra more attributes
+func copyObject(object map[string]interface{}, extra int) map[string]interface{} {
+	copied := make(map[string]interface{}, len(object)+extra)
+	for attribute, value := range object {
+		copied[attribute] = value
+	}
+	return copied
 }
 
-// Set sets the element at the given path to value
+// copyArray returns a shallow copy of array, with room for extra more elements
+func copyArray(array []interface{}, extra int) []interface{} {
+	copied := make([]interface{}, len(array), len(array)+extra)
+	copy(copied, array)
+	return copied
+}
+
+// Set sets the element at the given path to value.
+// The value is converted to what encoding/json would decode from its JSON encoding,
+// so that later modifications can address what is inside it.
+// The path is parsed when the modification is built; use SetPath with a compiled path
+// to get invalid paths reported at that time rather than when the modification is applied.
 func Set(path string, value interface{}) JSONModification {
-	return func(toModify interface{}) (interface{}, error) {
-		pathSegments, err := parseJSONPath(path)
-		if err != nil {
-			return nil, err
+	compiled, err := compiledPaths.compile(path)
+	if err != nil {
+		return failingModification(err)
+	}
+	return SetPath(compiled, value)
+}
+
+// normalizeValue converts value to untyped JSON data, made only of maps, slices, strings, float64, bools and nils.
+// Values that already are untyped JSON data are copied without going through encoding/json.
+func normalizeValue(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil, bool, string, float64:
+		return value, nil
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(value))
+		for attribute, element := range value {
+			normalizedElement, err := normalizeValue(element)
+			if err != nil {
+				return nil, err
+			}
+			normalized[attribute] = normalizedElement
+		}
+		return normalized, nil
+	case []interface{}:
+		normalized := make([]interface{}, len(value))
+		for i, element := range value {
+			normalizedElement, err := normalizeValue(element)
+			if err != nil {
+				return nil, err
+			}
+			normalized[i] = normalizedElement
 		}
-		return set(toModify, pathSegments, value)
+		return normalized, nil
+	default:
+		return toUntypedJSON(value)
+	}
+}
+
+// toUntypedJSON converts value to what encoding/json would produce when decoding its JSON encoding
+func toUntypedJSON(value interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
 	}
+	var untyped interface{}
+	if err := json.Unmarshal(encoded, &untyped); err != nil {
+		return nil, err
+	}
+	return untyped, nil
 }
 
 func set(toModify interface{}, parsedPath []jsonPathSegment, value interface{}) (interface{}, error) {
@@ -126,9 +193,10 @@ func set(toModify interface{}, parsedPath []jsonPathSegment, value interface{})
 		if err != nil {
 			return nil, err
 		}
-		toModify[*parsedPath[0].attribute] = modifiedDeeper
+		modified := copyObject(toModify, 1)
+		modified[*parsedPath[0].attribute] = modifiedDeeper
 
-		return toModify, nil
+		return modified, nil
 	case []interface{}:
 		if parsedPath[0].index == nil {
 			return nil, errors.New("cannot address content of JSON array by attribute")
@@ -143,15 +211,18 @@ func set(toModify interface{}, parsedPath []jsonPathSegment, value interface{})
 			deeper = toModify[index]
 		}
 
-		if modifiedDeeper, err := set(deeper, parsedPath[1:], value); err != nil {
+		modifiedDeeper, err := set(deeper, parsedPath[1:], value)
+		if err != nil {
 			return nil, err
-		} else if index == len(toModify) {
-			toModify = append(toModify, modifiedDeeper)
+		}
+		modified := copyArray(toModify, 1)
+		if index == len(toModify) {
+			modified = append(modified, modifiedDeeper)
 		} else {
-			toModify[index] = modifiedDeeper
+			modified[index] = modifiedDeeper
 		}
 
-		return toModify, nil
+		return modified, nil
 	case nil:
 		var deeper interface{} = make(map[string]interface{}, 1)
 		if parsedPath[0].attribute == nil {
@@ -163,25 +234,104 @@ func set(toModify interface{}, parsedPath []jsonPathSegment, value interface{})
 	}
 }
 
-// Modify applies modifications to a json string
+// get returns the element at the given path, and whether it was found
+func get(toModify interface{}, parsedPath []jsonPathSegment) (interface{}, bool) {
+	if len(parsedPath) == 0 {
+		return toModify, true
+	}
+	switch toModify := toModify.(type) {
+	case map[string]interface{}:
+		if parsedPath[0].attribute == nil {
+			return nil, false
+		}
+		deeper, ok := toModify[*parsedPath[0].attribute]
+		if !ok {
+			return nil, false
+		}
+		return get(deeper, parsedPath[1:])
+	case []interface{}:
+		if parsedPath[0].index == nil {
+			return nil, false
+		}
+		index := *parsedPath[0].index
+		if index < 0 || len(toModify) <= index {
+			return nil, false
+		}
+		return get(toModify[index], parsedPath[1:])
+	default:
+		return nil, false
+	}
+}
+
+// Modify applies modifications to a json string.
+// The output is compact, with object attributes sorted and without duplicates, as written by encoding/json.
+// When input is already written that way, as with the output of Modify or json.Marshal, and every modification
+// is made by Set, Remove, SetPath or RemovePath, they are applied directly to the bytes of input instead of
+// decoding it, which gives the same output faster for large documents.
 func Modify(input string, modifications ...JSONModification) (string, error) {
-	var untypedParsed interface{}
-	if err := json.Unmarshal([]byte(input), &untypedParsed); err != nil {
-		return "", err
+	if output, ok := modifyInPlace(input, modifications); ok {
+		return output, nil
 	}
 
-	for _, modification := range modifications {
-		var err error
-		if untypedParsed, err = modification(untypedParsed); err != nil {
-			return "", err
-		}
+	untypedParsed, err := unmarshalString(input)
+	if err != nil {
+		return "", err
 	}
 
-	result, err := json.Marshal(untypedParsed)
+	untypedParsed, err = applyModifications(untypedParsed, modifications)
 	if err != nil {
 		return "", err
 	}
-	return string(result), err
+
+	buffer := getBuffer()
+	defer putBuffer(buffer)
+	if err := json.NewEncoder(buffer).Encode(untypedParsed); err != nil {
+		return "", err
+	}
+	// Encode terminates the value with a newline, which Marshal does not
+	return string(bytes.TrimSuffix(buffer.Bytes(), []byte{'\n'})), nil
+}
+
+// Get returns the JSON encoding of the element at the given path of a json string, and whether it was found.
+// The empty path designates the whole document.
+func Get(input, path string) (string, bool, error) {
+	pathSegments, err := parseJSONPathOrRoot(path)
+	if err != nil {
+		return "", false, err
+	}
+	untypedParsed, err := unmarshalString(input)
+	if err != nil {
+		return "", false, err
+	}
+
+	value, found := get(untypedParsed, pathSegments)
+	if !found {
+		return "", false, nil
+	}
+	result, err := json.Marshal(value)
+	if err != nil {
+		return "", false, err
+	}
+	return string(result), true, nil
+}
+
+// Apply applies modifications in order to parsed untyped json data, as produced by encoding/json.
+// The document is never mutated, so that it can be shared between goroutines: the result is a copy
+// that shares the elements the modifications left untouched. This holds for the modifications
+// of this package; custom modifications given to Apply must not mutate their input either.
+func Apply(document interface{}, modifications ...JSONModification) (interface{}, error) {
+	return applyModifications(document, modifications)
+}
+
+// applyModifications applies modifications in order, stopping at the first error
+func applyModifications(toModify interface{}, modifications []JSONModification) (interface{}, error) {
+	for _, modification := range modifications {
+		var err error
+		if toModify, err = modification(toModify); err != nil {
+			return nil, err
+		}
+	}
+	return toModify, nil
 }
 
 // ModifyOrPanic calls Modify and panic if it returns an error.
@@ -219,6 +369,14 @@ func parseJSONPath(path string) ([]jsonPathSegment, error) {
 	return pathSegments, nil
 }
 
+// parseJSONPathOrRoot parses path like parseJSONPath, but accepts the empty path which designates the whole document
+func parseJSONPathOrRoot(path string) ([]jsonPathSegment, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return parseJSONPath(path)
+}
+
 func parseFirstSegment(path string) (jsonPathSegment, string, error) {
 	if path[0] == '.' {
 		path = path[1:]
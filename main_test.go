@@ -247,6 +247,59 @@ func TestModify(t *testing.T) {
 			},
 			expectedError: errors.New(`json: unsupported value: encountered a cycle via *slowjsonmutator.dummyStructWithCycle`),
 		},
+		"set inside an object previously set from raw json": {
+			input: `{"name": "Perceval"}`,
+			modifications: []JSONModification{
+				Set("manager", json.RawMessage(`{"name": "Arthur"}`)),
+				Set("manager.title", "King"),
+			},
+			expectedOutput: `{
+				"name": "Perceval",
+				"manager": {
+					"name": "Arthur",
+					"title": "King"
+				}
+			}`,
+		},
+		"set inside an object previously set from a struct": {
+			input: `{}`,
+			modifications: []JSONModification{
+				Set("manager", struct {
+					Name   string   `json:"name"`
+					Titles []string `json:"titles,omitempty"`
+					Home   string   `json:"-"`
+				}{Name: "Arthur", Home: "Kaamelott"}),
+				Set("manager.titles[0]", "King"),
+			},
+			expectedOutput: `{"manager": {"name": "Arthur", "titles": ["King"]}}`,
+		},
+		"set inside typed maps and slices": {
+			input: `{}`,
+			modifications: []JSONModification{
+				Set("manager", map[string]string{"name": "Arthur"}),
+				Set("titles", []string{"Knight"}),
+				Set("manager.title", "King"),
+				Set("titles[1]", "Provençal"),
+				Remove("titles[0]"),
+			},
+			expectedOutput: `{"manager": {"name": "Arthur", "title": "King"}, "titles": ["Provençal"]}`,
+		},
+		"set inside a value produced by a json marshaler": {
+			input: `{}`,
+			modifications: []JSONModification{
+				Set("manager", testMarshaler{name: "Arthur"}),
+				Set("manager.title", "King"),
+			},
+			expectedOutput: `{"manager": {"name": "Arthur", "title": "King"}}`,
+		},
+		"set inside generic data containing typed values": {
+			input: `{}`,
+			modifications: []JSONModification{
+				Set("manager", map[string]interface{}{"home": map[string]int{"towers": 4}}),
+				Set("manager.home.moat", true),
+			},
+			expectedOutput: `{"manager": {"home": {"towers": 4, "moat": true}}}`,
+		},
 		"remove one nested attribute inside an array": {
 			input: `{
 				"knights": [
@@ -396,6 +449,70 @@ func TestModify(t *testing.T) {
 	}
 }
 
+func TestGet(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		path           string
+		expectedOutput string
+		expectedFound  bool
+		expectedError  error
+	}{
+		"nested attribute": {
+			input:          `{"manager": {"titles": [{"fr": "Suzerain"}]}}`,
+			path:           "manager.titles[0]",
+			expectedOutput: `{"fr":"Suzerain"}`,
+			expectedFound:  true,
+		},
+		"whole document": {
+			input:          `[1, 2]`,
+			path:           "",
+			expectedOutput: `[1,2]`,
+			expectedFound:  true,
+		},
+		"null attribute": {
+			input:          `{"manager": null}`,
+			path:           "manager",
+			expectedOutput: `null`,
+			expectedFound:  true,
+		},
+		"missing attribute": {
+			input: `{"manager": {"name": "Arthur"}}`,
+			path:  "manager.titles[0]",
+		},
+		"invalid path": {
+			input:         `{}`,
+			path:          "a..b",
+			expectedError: errors.New(`cannot parse json path ["a..b"], it doesn't seem valid`),
+		},
+		"invalid json": {
+			input:         `{`,
+			path:          "name",
+			expectedError: errors.New("unexpected end of JSON input"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, found, err := Get(test.input, test.path)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if output != test.expectedOutput || found != test.expectedFound {
+				t.Errorf("unexpected output: wanted [%v, %v], got [%v, %v]", test.expectedOutput, test.expectedFound, output, found)
+			}
+		})
+	}
+}
+
+type testMarshaler struct {
+	name string
+}
+
+func (m testMarshaler) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"name": m.name})
+}
+
 func TestParseJsonPath(t *testing.T) {
 	tests := map[string]struct {
 		inputPath        string
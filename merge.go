@@ -0,0 +1,139 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ArrayMergeStrategy defines how Merge combines two arrays
+type ArrayMergeStrategy int
+
+// Array merge strategies
+const (
+	// ArrayReplace replaces the whole array, like any other conflicting value
+	ArrayReplace ArrayMergeStrategy = iota
+	// ArrayConcat appends the elements of the patch to those of the original array
+	ArrayConcat
+	// ArrayMergeByIndex merges elements that have the same index, and appends extra elements of the patch
+	ArrayMergeByIndex
+	// ArrayMergeByKey merges objects that have the same value for MergeOptions.ArrayKey, and appends the others
+	ArrayMergeByKey
+)
+
+// MergeOptions configures Merge
+type MergeOptions struct {
+	// Arrays is the strategy used when both the original and the patch hold an array
+	Arrays ArrayMergeStrategy
+	// ArrayKey is the attribute identifying objects in arrays, when using ArrayMergeByKey
+	ArrayKey string
+	// KeepOriginal makes the original value win conflicts, instead of the patch value.
+	// The patch then only adds what is missing from the original.
+	KeepOriginal bool
+}
+
+// Merge deep-merges value into the element at the given path.
+// Objects are merged attribute by attribute, arrays according to options,
+// and any other conflict between two values is resolved according to options.
+// An empty path designates the whole document.
+func Merge(path string, value interface{}, options MergeOptions) JSONModification {
+	return func(toModify interface{}) (interface{}, error) {
+		pathSegments, err := parseJSONPathOrRoot(path)
+		if err != nil {
+			return nil, err
+		}
+		if options.Arrays == ArrayMergeByKey && options.ArrayKey == "" {
+			return nil, errors.New("an array key is required to merge arrays by key")
+		}
+		patch, err := normalizeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot merge value at path [%q]: %w", path, err)
+		}
+		original, found := get(toModify, pathSegments)
+		if !found {
+			return set(toModify, pathSegments, patch)
+		}
+		return set(toModify, pathSegments, merge(original, patch, options))
+	}
+}
+
+func merge(original, patch interface{}, options MergeOptions) interface{} {
+	switch original := original.(type) {
+	case map[string]interface{}:
+		if patch, ok := patch.(map[string]interface{}); ok {
+			return mergeObjects(original, patch, options)
+		}
+	case []interface{}:
+		if patch, ok := patch.([]interface{}); ok {
+			return mergeArrays(original, patch, options)
+		}
+	}
+	if options.KeepOriginal {
+		return original
+	}
+	return patch
+}
+
+func mergeObjects(original, patch map[string]interface{}, options MergeOptions) map[string]interface{} {
+	merged := copyObject(original, len(patch))
+	for attribute, patchValue := range patch {
+		if originalValue, ok := original[attribute]; ok {
+			merged[attribute] = merge(originalValue, patchValue, options)
+		} else {
+			merged[attribute] = patchValue
+		}
+	}
+	return merged
+}
+
+func mergeArrays(original, patch []interface{}, options MergeOptions) []interface{} {
+	switch options.Arrays {
+	case ArrayConcat:
+		return append(copyArray(original, len(patch)), patch...)
+	case ArrayMergeByIndex:
+		merged := copyArray(original, len(patch))
+		for i, patchElement := range patch {
+			if i < len(merged) {
+				merged[i] = merge(merged[i], patchElement, options)
+			} else {
+				merged = append(merged, patchElement)
+			}
+		}
+		return merged
+	case ArrayMergeByKey:
+		merged := copyArray(original, len(patch))
+		for _, patchElement := range patch {
+			if i := indexByKey(merged, patchElement, options.ArrayKey); i != -1 {
+				merged[i] = merge(merged[i], patchElement, options)
+			} else {
+				merged = append(merged, patchElement)
+			}
+		}
+		return merged
+	default:
+		if options.KeepOriginal {
+			return original
+		}
+		return patch
+	}
+}
+
+// indexByKey returns the index of the first object of array that has the same value for key as element, or -1
+func indexByKey(array []interface{}, element interface{}, key string) int {
+	object, ok := element.(map[string]interface{})
+	if !ok {
+		return -1
+	}
+	keyValue, ok := object[key]
+	if !ok {
+		return -1
+	}
+	for i, candidate := range array {
+		if candidate, ok := candidate.(map[string]interface{}); ok {
+			if candidateKeyValue, ok := candidate[key]; ok && reflect.DeepEqual(candidateKeyValue, keyValue) {
+				return i
+			}
+		}
+	}
+	return -1
+}
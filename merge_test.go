@@ -0,0 +1,122 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		modifications  []JSONModification
+		expectedOutput string
+		expectedError  error
+	}{
+		"merge objects": {
+			input: `{"manager": {"name": "Arthur", "home": {"type": "Castle"}}}`,
+			modifications: []JSONModification{
+				Merge("manager", json.RawMessage(`{"title": "King", "home": {"name": "Kaamelott"}}`), MergeOptions{}),
+			},
+			expectedOutput: `{"manager": {"name": "Arthur", "title": "King", "home": {"type": "Castle", "name": "Kaamelott"}}}`,
+		},
+		"merge into a missing element": {
+			input: `{}`,
+			modifications: []JSONModification{
+				Merge("manager", map[string]string{"name": "Arthur"}, MergeOptions{}),
+			},
+			expectedOutput: `{"manager": {"name": "Arthur"}}`,
+		},
+		"merge into the whole document": {
+			input: `{"name": "Perceval"}`,
+			modifications: []JSONModification{
+				Merge("", json.RawMessage(`{"title": "Knight"}`), MergeOptions{}),
+			},
+			expectedOutput: `{"name": "Perceval", "title": "Knight"}`,
+		},
+		"conflicts are won by the patch by default": {
+			input: `{"name": "Perceval", "quests": 0}`,
+			modifications: []JSONModification{
+				Merge("", json.RawMessage(`{"name": {"first": "Perceval"}, "quests": 1}`), MergeOptions{}),
+			},
+			expectedOutput: `{"name": {"first": "Perceval"}, "quests": 1}`,
+		},
+		"conflicts are won by the original when asked": {
+			input: `{"name": "Perceval", "quests": 0}`,
+			modifications: []JSONModification{
+				Merge("", json.RawMessage(`{"name": "Karadoc", "quests": 1, "title": "Knight"}`), MergeOptions{KeepOriginal: true}),
+			},
+			expectedOutput: `{"name": "Perceval", "quests": 0, "title": "Knight"}`,
+		},
+		"arrays are replaced by default": {
+			input: `{"titles": ["Knight", "Provençal"]}`,
+			modifications: []JSONModification{
+				Merge("", json.RawMessage(`{"titles": ["Le Gaulois"]}`), MergeOptions{}),
+			},
+			expectedOutput: `{"titles": ["Le Gaulois"]}`,
+		},
+		"arrays are kept when the original wins": {
+			input: `{"titles": ["Knight", "Provençal"]}`,
+			modifications: []JSONModification{
+				Merge("", json.RawMessage(`{"titles": ["Le Gaulois"]}`), MergeOptions{KeepOriginal: true}),
+			},
+			expectedOutput: `{"titles": ["Knight", "Provençal"]}`,
+		},
+		"arrays are concatenated": {
+			input: `{"titles": ["Knight", "Provençal"]}`,
+			modifications: []JSONModification{
+				Merge("titles", []string{"Le Gaulois"}, MergeOptions{Arrays: ArrayConcat}),
+			},
+			expectedOutput: `{"titles": ["Knight", "Provençal", "Le Gaulois"]}`,
+		},
+		"arrays are merged by index": {
+			input: `{"knights": [{"name": "Perceval"}, {"name": "Karadoc"}]}`,
+			modifications: []JSONModification{
+				Merge("knights", json.RawMessage(`[{"title": "Knight"}, {}, {"name": "Lancelot"}]`), MergeOptions{Arrays: ArrayMergeByIndex}),
+			},
+			expectedOutput: `{"knights": [{"name": "Perceval", "title": "Knight"}, {"name": "Karadoc"}, {"name": "Lancelot"}]}`,
+		},
+		"arrays are merged by key": {
+			input: `{"knights": [{"id": 1, "name": "Perceval"}, {"id": 2, "name": "Karadoc"}]}`,
+			modifications: []JSONModification{
+				Merge("knights", json.RawMessage(`[{"id": 2, "title": "Knight"}, {"id": 3, "name": "Lancelot"}, "Bohort"]`), MergeOptions{Arrays: ArrayMergeByKey, ArrayKey: "id"}),
+			},
+			expectedOutput: `{"knights": [
+				{"id": 1, "name": "Perceval"},
+				{"id": 2, "name": "Karadoc", "title": "Knight"},
+				{"id": 3, "name": "Lancelot"},
+				"Bohort"
+			]}`,
+		},
+		"merging arrays by key requires a key": {
+			input: `{}`,
+			modifications: []JSONModification{
+				Merge("knights", []string{}, MergeOptions{Arrays: ArrayMergeByKey}),
+			},
+			expectedError: errors.New("an array key is required to merge arrays by key"),
+		},
+		"merge a value that cannot be encoded": {
+			input: `{}`,
+			modifications: []JSONModification{
+				Merge("knights", make(chan int), MergeOptions{}),
+			},
+			expectedError: errors.New(`cannot merge value at path ["knights"]: json: unsupported type: chan int`),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := Modify(test.input, test.modifications...)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if output == test.expectedOutput {
+				return
+			}
+			if message, ok := JSONEqual(output, test.expectedOutput); !ok {
+				t.Error("unexpected output: " + message)
+			}
+		})
+	}
+}
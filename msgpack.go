@@ -0,0 +1,207 @@
+package slowjsonmutator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// msgpackTimestampExtension is the extension type of MessagePack timestamps
+const msgpackTimestampExtension = -1
+
+// ModifyMessagePack applies modifications to a MessagePack value, using the same paths as with JSON.
+// The value is converted to JSON data, modified, and converted back to MessagePack.
+// What modifications leave untouched is written back byte for byte, and key order is kept;
+// new attributes are appended, sorted by name, to the maps that receive them.
+// Modified values keep the type and width of the value they replace when they fit:
+// integers keep their signedness and width, and 32-bit floats stay so when exact.
+// Binary values are base64 strings in JSON, and timestamps are strings in RFC 3339 format;
+// strings set in place of them must be in the same format.
+// Maps with keys that are not strings, integers that JSON numbers cannot hold exactly,
+// extension types other than timestamps and NaN or infinite floats are rejected.
+func ModifyMessagePack(input []byte, modifications ...JSONModification) ([]byte, error) {
+	return modifyBinary(msgpackFormat{}, input, modifications)
+}
+
+type msgpackFormat struct{}
+
+func (f msgpackFormat) parse(raw []byte) (*binaryNode, error) {
+	reader := bytes.NewReader(raw)
+	if _, err := msgpack.NewDecoder(reader).DecodeRaw(); err != nil {
+		return nil, fmt.Errorf("failed to parse MessagePack: %w", err)
+	}
+	if reader.Len() != 0 {
+		return nil, errors.New("unexpected data after MessagePack value")
+	}
+	return f.parseValue(raw)
+}
+
+// parseValue decodes a well-formed MessagePack value
+func (f msgpackFormat) parseValue(raw []byte) (*binaryNode, error) {
+	node := &binaryNode{raw: raw, head: raw[0], hasHead: true}
+	decoder := msgpack.NewDecoder(bytes.NewReader(raw))
+	code := raw[0]
+	switch {
+	case msgpcode.IsFixedMap(code) || code == msgpcode.Map16 || code == msgpcode.Map32:
+		node.kind = binaryObject
+		length, err := decoder.DecodeMapLen()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < length; i++ {
+			rawKey, err := decoder.DecodeRaw()
+			if err != nil {
+				return nil, err
+			}
+			entry := &binaryEntry{rawKey: rawKey}
+			if !msgpcode.IsString(rawKey[0]) {
+				return nil, fmt.Errorf("cannot use MessagePack map key of code 0x%x as a JSON attribute", rawKey[0])
+			}
+			if err := msgpack.Unmarshal(rawKey, &entry.key); err != nil {
+				return nil, err
+			}
+			if entry.node, err = f.parseEntry(decoder); err != nil {
+				return nil, err
+			}
+			node.entries = append(node.entries, entry)
+		}
+	case msgpcode.IsFixedArray(code) || code == msgpcode.Array16 || code == msgpcode.Array32:
+		node.kind = binaryArray
+		length, err := decoder.DecodeArrayLen()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < length; i++ {
+			element, err := f.parseEntry(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.entries = append(node.entries, &binaryEntry{node: element})
+		}
+	case msgpcode.IsExt(code):
+		extension, _, err := decoder.DecodeExtHeader()
+		if err != nil {
+			return nil, err
+		}
+		if extension != msgpackTimestampExtension {
+			return nil, fmt.Errorf("cannot represent MessagePack extension type %d as JSON", extension)
+		}
+		var timestamp time.Time
+		if err := msgpack.Unmarshal(raw, &timestamp); err != nil {
+			return nil, err
+		}
+		node.value = timestamp.UTC().Format(time.RFC3339Nano)
+	default:
+		var value interface{}
+		if err := msgpack.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		var err error
+		if node.value, err = msgpackJSONValue(value); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+func (f msgpackFormat) parseEntry(decoder *msgpack.Decoder) (*binaryNode, error) {
+	raw, err := decoder.DecodeRaw()
+	if err != nil {
+		return nil, err
+	}
+	return f.parseValue(raw)
+}
+
+// msgpackJSONValue converts what the msgpack package decodes into an interface{} to a JSON value
+func msgpackJSONValue(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case nil, bool, string:
+		return value, nil
+	case int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		return exactJSONInteger(value)
+	case float32:
+		return jsonFloat(float64(value))
+	case float64:
+		return jsonFloat(value)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(value), nil
+	default:
+		return nil, fmt.Errorf("cannot represent MessagePack value [%v] of type %T as JSON", value, value)
+	}
+}
+
+func (msgpackFormat) appendHead(buffer *bytes.Buffer, node *binaryNode, count int) error {
+	if node.kind == binaryObject {
+		return msgpack.NewEncoder(buffer).EncodeMapLen(count)
+	}
+	return msgpack.NewEncoder(buffer).EncodeArrayLen(count)
+}
+
+func (msgpackFormat) appendScalar(buffer *bytes.Buffer, node *binaryNode) error {
+	encoder := msgpack.NewEncoder(buffer)
+	code := node.head
+	if !node.hasHead {
+		code = msgpcode.Nil
+	}
+	wasFloat := code == msgpcode.Float || code == msgpcode.Double
+	if integer, ok := integralValue(node.value); ok && !wasFloat {
+		return appendMessagePackInteger(encoder, code, integer)
+	}
+
+	switch value := node.value.(type) {
+	case float64:
+		if code == msgpcode.Float && float64(float32(value)) == value {
+			return encoder.EncodeFloat32(float32(value))
+		}
+		return encoder.EncodeFloat64(value)
+	case string:
+		switch {
+		case msgpcode.IsBin(code):
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return fmt.Errorf("cannot write [%q] in place of a MessagePack binary value, it is not base64: %w", value, err)
+			}
+			return encoder.EncodeBytes(decoded)
+		case msgpcode.IsExt(code):
+			timestamp, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return fmt.Errorf("cannot write [%q] in place of a MessagePack timestamp: %w", value, err)
+			}
+			return encoder.EncodeTime(timestamp)
+		default:
+			return encoder.EncodeString(value)
+		}
+	default:
+		return encoder.Encode(value)
+	}
+}
+
+// appendMessagePackInteger encodes integer with the type of code if it fits, or in the shortest form otherwise
+func appendMessagePackInteger(encoder *msgpack.Encoder, code byte, integer int64) error {
+	switch {
+	case code == msgpcode.Uint8 && 0 <= integer && integer <= math.MaxUint8:
+		return encoder.EncodeUint8(uint8(integer))
+	case code == msgpcode.Uint16 && 0 <= integer && integer <= math.MaxUint16:
+		return encoder.EncodeUint16(uint16(integer))
+	case code == msgpcode.Uint32 && 0 <= integer && integer <= math.MaxUint32:
+		return encoder.EncodeUint32(uint32(integer))
+	case code == msgpcode.Uint64 && 0 <= integer:
+		return encoder.EncodeUint64(uint64(integer))
+	case code == msgpcode.Int8 && math.MinInt8 <= integer && integer <= math.MaxInt8:
+		return encoder.EncodeInt8(int8(integer))
+	case code == msgpcode.Int16 && math.MinInt16 <= integer && integer <= math.MaxInt16:
+		return encoder.EncodeInt16(int16(integer))
+	case code == msgpcode.Int32 && math.MinInt32 <= integer && integer <= math.MaxInt32:
+		return encoder.EncodeInt32(int32(integer))
+	case code == msgpcode.Int64:
+		return encoder.EncodeInt64(integer)
+	default:
+		return encoder.EncodeInt(integer)
+	}
+}
@@ -0,0 +1,83 @@
+package slowjsonmutator
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestModifyMessagePack(t *testing.T) {
+	// {"name": "Perceval", "id": uint16 1, "avatar": bin 0102, "born": 2020-01-02T03:04:05Z, "score": float32 1.5, "ratio": int8 -1}
+	knight := "86" + "a46e616d65" + "a8506572636576616c" + "a26964" + "cd0001" + "a6617661746172" + "c4020102" +
+		"a4626f726e" + "d6ff5e0d5da5" + "a573636f7265" + "ca3fc00000" + "a5726174696f" + "d0ff"
+
+	tests := map[string]struct {
+		input          string
+		modifications  []JSONModification
+		expectedOutput string
+		expectedError  error
+	}{
+		"types and widths are kept": {
+			input: knight,
+			modifications: []JSONModification{Set("name", "Karadoc"), Set("id", 2), Set("avatar", "AwQ="), Set("born", "2021-01-02T03:04:05Z"),
+				Set("score", 2.5), Set("ratio", -2), Set("title", "Knight")},
+			expectedOutput: "87" + "a46e616d65" + "a74b617261646f63" + "a26964" + "cd0002" + "a6617661746172" + "c4020304" +
+				"a4626f726e" + "d6ff5fefe2a5" + "a573636f7265" + "ca40200000" + "a5726174696f" + "d0fe" + "a57469746c65" + "a64b6e69676874",
+		},
+		"integers that do not fit are widened": {
+			input:          knight,
+			modifications:  []JSONModification{Set("ratio", -300)},
+			expectedOutput: knight[:len(knight)-4] + "d1fed4",
+		},
+		"untouched values are kept byte for byte": {
+			input:          knight,
+			expectedOutput: knight,
+		},
+		"elements after a removed one are kept byte for byte": {
+			// [int8 1, bin "hi", float32 1.5]
+			input:          "93" + "d001" + "c4026869" + "ca3fc00000",
+			modifications:  []JSONModification{Remove("[0]")},
+			expectedOutput: "92" + "c4026869" + "ca3fc00000",
+		},
+		"elements around a removed one are kept byte for byte": {
+			input:          "94" + "d001" + "c4026869" + "ca3fc00000" + "cd0001",
+			modifications:  []JSONModification{Remove("[1]")},
+			expectedOutput: "93" + "d001" + "ca3fc00000" + "cd0001",
+		},
+		"string in place of a timestamp": {
+			input:         knight,
+			modifications: []JSONModification{Set("born", "yesterday")},
+			expectedError: errors.New(`cannot write ["yesterday"] in place of a MessagePack timestamp: parsing time "yesterday" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "yesterday" as "2006"`),
+		},
+		"map key that is not a string": {
+			input:         "8101a161",
+			expectedError: errors.New("cannot use MessagePack map key of code 0x1 as a JSON attribute"),
+		},
+		"extension type": {
+			input:         "c70501aabbccddee",
+			expectedError: errors.New("cannot represent MessagePack extension type 1 as JSON"),
+		},
+		"integer too large for JSON": {
+			input:         "cf1000000000000000",
+			expectedError: errors.New("cannot represent integer [1152921504606846976] exactly as a JSON number"),
+		},
+		"data after the value": {
+			input:         "0102",
+			expectedError: errors.New("unexpected data after MessagePack value"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			input, _ := hex.DecodeString(test.input)
+			output, err := ModifyMessagePack(input, test.modifications...)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if hex.EncodeToString(output) != test.expectedOutput {
+				t.Errorf("unexpected output: wanted [%v], got [%x]", test.expectedOutput, output)
+			}
+		})
+	}
+}
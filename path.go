@@ -0,0 +1,129 @@
+package slowjsonmutator
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+)
+
+// pathCacheCapacity is the number of string paths whose parsing is kept by Set and Remove
+const pathCacheCapacity = 1024
+
+var errUncompiledPath = errors.New("path was not compiled with CompilePath")
+
+// Path is a parsed path, that can be reused by modifications without being parsed again
+type Path struct {
+	raw      string
+	segments []jsonPathSegment
+}
+
+// CompilePath parses a path such as "manager.titles[0].fr", so that it can be given to SetPath and RemovePath
+func CompilePath(path string) (Path, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return Path{}, err
+	}
+	return Path{raw: path, segments: segments[:len(segments):len(segments)]}, nil
+}
+
+// MustCompilePath is like CompilePath, but panics if the path is invalid.
+// It is meant to initialize package level variables.
+func MustCompilePath(path string) Path {
+	compiled, err := CompilePath(path)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+// String returns the path as it was given to CompilePath
+func (p Path) String() string {
+	return p.raw
+}
+
+// SetPath is like Set, with a compiled path
+func SetPath(path Path, value interface{}) JSONModification {
+	modification := JSONModification(func(toModify interface{}) (interface{}, error) {
+		if len(path.segments) == 0 {
+			return nil, errUncompiledPath
+		}
+		normalizedValue, err := normalizeValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return set(toModify, path.segments, normalizedValue)
+	})
+	if len(path.segments) != 0 {
+		recentEdits.register(modification, edit{segments: path.segments, value: value})
+	}
+	return modification
+}
+
+// RemovePath is like Remove, with a compiled path
+func RemovePath(path Path) JSONModification {
+	modification := JSONModification(func(toModify interface{}) (interface{}, error) {
+		if len(path.segments) == 0 {
+			return nil, errUncompiledPath
+		}
+		return remove(toModify, path.segments)
+	})
+	if len(path.segments) != 0 {
+		recentEdits.register(modification, edit{segments: path.segments, remove: true})
+	}
+	return modification
+}
+
+// failingModification returns a modification that always fails with err
+func failingModification(err error) JSONModification {
+	return func(interface{}) (interface{}, error) {
+		return nil, err
+	}
+}
+
+// pathCache is a least recently used cache of compiled paths, safe for concurrent use
+type pathCache struct {
+	mutex    sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	// order holds compiled paths, the most recently used first
+	order *list.List
+}
+
+func newPathCache(capacity int) *pathCache {
+	return &pathCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+var compiledPaths = newPathCache(pathCacheCapacity)
+
+// compile returns the compiled path, parsing it only if it is not in the cache.
+// Invalid paths are not cached.
+func (c *pathCache) compile(path string) (Path, error) {
+	c.mutex.Lock()
+	if element, ok := c.entries[path]; ok {
+		c.order.MoveToFront(element)
+		c.mutex.Unlock()
+		return element.Value.(Path), nil
+	}
+	c.mutex.Unlock()
+
+	compiled, err := CompilePath(path)
+	if err != nil {
+		return Path{}, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.entries[path]; !ok {
+		c.entries[path] = c.order.PushFront(compiled)
+		if c.order.Len() > c.capacity {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(Path).raw)
+		}
+	}
+	return compiled, nil
+}
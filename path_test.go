@@ -0,0 +1,138 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestCompilePath(t *testing.T) {
+	tests := map[string]struct {
+		path           string
+		modification   func(Path) JSONModification
+		input          string
+		expectedOutput string
+		expectedError  error
+	}{
+		"set with a compiled path": {
+			path:           "manager.titles[0].fr",
+			modification:   func(path Path) JSONModification { return SetPath(path, "Suzerain") },
+			input:          `{"name": "Perceval"}`,
+			expectedOutput: `{"name": "Perceval", "manager": {"titles": [{"fr": "Suzerain"}]}}`,
+		},
+		"remove with a compiled path": {
+			path:           "titles[1]",
+			modification:   RemovePath,
+			input:          `{"titles": ["Knight", "Le Gaulois"]}`,
+			expectedOutput: `{"titles": ["Knight"]}`,
+		},
+		"invalid path": {
+			path:          "a..b",
+			expectedError: errors.New(`cannot parse json path ["a..b"], it doesn't seem valid`),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path, err := CompilePath(test.path)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if path.String() != test.path {
+				t.Errorf("unexpected path string: wanted [%v], got [%v]", test.path, path.String())
+			}
+			output, err := Modify(test.input, test.modification(path))
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if message, ok := JSONEqual(output, test.expectedOutput); !ok {
+				t.Error("unexpected output: " + message)
+			}
+		})
+	}
+}
+
+func TestMustCompilePath(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	MustCompilePath("[")
+}
+
+func TestUncompiledPath(t *testing.T) {
+	for name, modification := range map[string]JSONModification{
+		"set":    SetPath(Path{}, 1),
+		"remove": RemovePath(Path{}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Modify(`{}`, modification); !ErrorEqual(err, errUncompiledPath) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", errUncompiledPath, err)
+			}
+		})
+	}
+}
+
+func TestCompiledPathIsReusable(t *testing.T) {
+	path := MustCompilePath("manager.titles")
+	if _, err := Modify(`{}`, SetPath(path, []interface{}{"Suzerain"}), Set("manager.titles[1]", "Roi")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output, err := Modify(`{}`, SetPath(path, []interface{}{"Suzerain"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `{"manager":{"titles":["Suzerain"]}}`; output != expected {
+		t.Errorf("unexpected output: wanted [%v], got [%v]", expected, output)
+	}
+}
+
+func TestPathCache(t *testing.T) {
+	cache := newPathCache(2)
+	for _, path := range []string{"a", "b", "a", "c"} {
+		if _, err := cache.compile(path); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := cache.compile("a..b"); err == nil {
+		t.Error("expected an error for an invalid path")
+	}
+
+	var cached []string
+	for element := cache.order.Front(); element != nil; element = element.Next() {
+		cached = append(cached, element.Value.(Path).String())
+	}
+	if diff := DeepEqual(cached, []string{"c", "a"}); diff != "" {
+		t.Errorf("unexpected cached paths: " + diff)
+	}
+	if len(cache.entries) != 2 {
+		t.Errorf("unexpected number of entries: %v", len(cache.entries))
+	}
+}
+
+func TestPathCacheConcurrentUse(t *testing.T) {
+	cache := newPathCache(8)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := cache.compile("knights[" + strconv.Itoa((i+j)%16) + "].name"); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if cache.order.Len() != 8 || len(cache.entries) != 8 {
+		t.Errorf("unexpected cache size: %v, %v", cache.order.Len(), len(cache.entries))
+	}
+}
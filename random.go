@@ -0,0 +1,258 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MutationKind is a kind of mutation that a RandomMutator can produce
+type MutationKind string
+
+// Mutation kinds
+const (
+	// MutationDropField removes an attribute of an object
+	MutationDropField MutationKind = "drop-field"
+	// MutationChangeType replaces a value by a value of another JSON type
+	MutationChangeType MutationKind = "change-type"
+	// MutationBoundaryNumber replaces a number by a number at the edge of common integer and float ranges
+	MutationBoundaryNumber MutationKind = "boundary-number"
+	// MutationLongString replaces a string by a very long one
+	MutationLongString MutationKind = "long-string"
+	// MutationUnicodeString replaces a string by one with unusual Unicode characters
+	MutationUnicodeString MutationKind = "unicode-string"
+	// MutationDuplicateArray appends to an array a copy of its elements
+	MutationDuplicateArray MutationKind = "duplicate-array"
+	// MutationEmptyArray removes all elements of an array
+	MutationEmptyArray MutationKind = "empty-array"
+)
+
+// AllMutationKinds lists every kind of mutation, in the order a RandomMutator considers them
+var AllMutationKinds = []MutationKind{
+	MutationDropField,
+	MutationChangeType,
+	MutationBoundaryNumber,
+	MutationLongString,
+	MutationUnicodeString,
+	MutationDuplicateArray,
+	MutationEmptyArray,
+}
+
+var (
+	boundaryNumbers = []float64{0, -1, 2147483647, 2147483648, -2147483649, 4294967296, 9007199254740992, -9007199254740992, 1e308, -1e308, 5e-324}
+	longStringSizes = []int{256, 1025, 65537}
+	unicodeStrings  = []string{
+		"Perceval \u202eedialaG",
+		"\u0000",
+		"👑🗡️🏰",
+		"Z̤͔ͧ̑̓ä͖̭̈̇lͮ̒ͫǧ̗͚̚o̙̔ͮ̇͐̇",
+		"ｆｕｌｌｗｉｄｔｈ",
+		"\ufeffbom",
+		"田中さんにあげて下さい",
+		"\u00a0\u2028\u200b",
+	}
+	typeChangeCandidates = []interface{}{nil, true, float64(1), "1", []interface{}{}, map[string]interface{}{}}
+)
+
+var errNoPossibleMutation = errors.New("no mutation can be applied to the document")
+
+// Mutation is a modification produced by a RandomMutator
+type Mutation struct {
+	Kind MutationKind
+	// Path is the path of the mutated element
+	Path string
+	// Value is the value set at Path, or nil if the element is removed
+	Value interface{}
+	// Modification applies the mutation
+	Modification JSONModification
+}
+
+// String returns the Go code of the modification, so that it can be copied to reproduce a failure
+func (m Mutation) String() string {
+	if m.Kind == MutationDropField {
+		return fmt.Sprintf("Remove(%q)", m.Path)
+	}
+	encoded, err := json.Marshal(m.Value)
+	if err != nil {
+		return fmt.Sprintf("Set(%q, %#v)", m.Path, m.Value)
+	}
+	return fmt.Sprintf("Set(%q, json.RawMessage(%s))", m.Path, strconv.Quote(string(encoded)))
+}
+
+// RandomMutator produces invalid variants of valid JSON documents, to test how robust their consumers are.
+// It is deterministic: two mutators created with the same seed and kinds produce the same mutations.
+type RandomMutator struct {
+	random chooser
+	kinds  []MutationKind
+}
+
+// chooser picks numbers in [0, n); *rand.Rand is one
+type chooser interface {
+	Intn(n int) int
+}
+
+// NewRandomMutator creates a RandomMutator that produces mutations of the given kinds, or of all kinds if there are none
+func NewRandomMutator(seed int64, kinds ...MutationKind) *RandomMutator {
+	if len(kinds) == 0 {
+		kinds = AllMutationKinds
+	}
+	return &RandomMutator{
+		random: rand.New(rand.NewSource(seed)),
+		kinds:  kinds,
+	}
+}
+
+// Mutate applies count random mutations to document, and returns the result along with the mutations applied.
+// Each mutation is chosen on the document produced by the previous ones,
+// so applying the mutations in order with Modify reproduces the result.
+func (m *RandomMutator) Mutate(document string, count int) (string, []Mutation, error) {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(document), &parsed); err != nil {
+		return "", nil, err
+	}
+
+	mutations := make([]Mutation, 0, count)
+	for len(mutations) < count {
+		mutation, err := m.nextMutation(parsed)
+		if err != nil {
+			return "", nil, err
+		}
+		if parsed, err = mutation.Modification(parsed); err != nil {
+			return "", nil, fmt.Errorf("failed to apply mutation %v: %w", mutation, err)
+		}
+		mutations = append(mutations, mutation)
+	}
+
+	result, err := json.Marshal(parsed)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(result), mutations, nil
+}
+
+// Modifications returns the modifications of mutations, to be given to Modify
+func Modifications(mutations []Mutation) []JSONModification {
+	modifications := make([]JSONModification, 0, len(mutations))
+	for _, mutation := range mutations {
+		modifications = append(modifications, mutation.Modification)
+	}
+	return modifications
+}
+
+type pathValue struct {
+	path  string
+	value interface{}
+	// attribute is true if the last segment of path is an attribute
+	attribute bool
+}
+
+func (m *RandomMutator) nextMutation(document interface{}) (Mutation, error) {
+	candidates := collectPathValues(document, "", nil)
+
+	type possibleMutation struct {
+		kind      MutationKind
+		candidate pathValue
+	}
+	var possibleMutations []possibleMutation
+	for _, kind := range m.kinds {
+		for _, candidate := range candidates {
+			if canMutate(kind, candidate) {
+				possibleMutations = append(possibleMutations, possibleMutation{kind: kind, candidate: candidate})
+			}
+		}
+	}
+	if len(possibleMutations) == 0 {
+		return Mutation{}, errNoPossibleMutation
+	}
+
+	chosen := possibleMutations[m.random.Intn(len(possibleMutations))]
+	mutation := Mutation{
+		Kind:  chosen.kind,
+		Path:  chosen.candidate.path,
+		Value: m.mutatedValue(chosen.kind, chosen.candidate.value),
+	}
+	if mutation.Kind == MutationDropField {
+		mutation.Modification = Remove(mutation.Path)
+	} else {
+		mutation.Modification = Set(mutation.Path, mutation.Value)
+	}
+	return mutation, nil
+}
+
+func canMutate(kind MutationKind, candidate pathValue) bool {
+	switch kind {
+	case MutationDropField:
+		return candidate.attribute
+	case MutationChangeType:
+		return true
+	case MutationBoundaryNumber:
+		return jsonTypeOf(candidate.value) == JSONNumber
+	case MutationLongString, MutationUnicodeString:
+		return jsonTypeOf(candidate.value) == JSONString
+	case MutationDuplicateArray, MutationEmptyArray:
+		array, ok := candidate.value.([]interface{})
+		return ok && len(array) != 0
+	default:
+		return false
+	}
+}
+
+func (m *RandomMutator) mutatedValue(kind MutationKind, value interface{}) interface{} {
+	switch kind {
+	case MutationChangeType:
+		var otherTypeValues []interface{}
+		for _, candidate := range typeChangeCandidates {
+			if jsonTypeOf(candidate) != jsonTypeOf(value) {
+				otherTypeValues = append(otherTypeValues, candidate)
+			}
+		}
+		return otherTypeValues[m.random.Intn(len(otherTypeValues))]
+	case MutationBoundaryNumber:
+		return boundaryNumbers[m.random.Intn(len(boundaryNumbers))]
+	case MutationLongString:
+		return strings.Repeat("a", longStringSizes[m.random.Intn(len(longStringSizes))])
+	case MutationUnicodeString:
+		return unicodeStrings[m.random.Intn(len(unicodeStrings))]
+	case MutationDuplicateArray:
+		array := value.([]interface{})
+		duplicated := make([]interface{}, 0, 2*len(array))
+		return append(append(duplicated, array...), array...)
+	case MutationEmptyArray:
+		return []interface{}{}
+	default:
+		return nil
+	}
+}
+
+// collectPathValues lists every element of value except value itself, in a deterministic order
+func collectPathValues(value interface{}, path string, collected []pathValue) []pathValue {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		attributes := make([]string, 0, len(value))
+		for attribute := range value {
+			if addressableAttributeRegexp.MatchString(attribute) {
+				attributes = append(attributes, attribute)
+			}
+		}
+		sort.Strings(attributes)
+		for _, attribute := range attributes {
+			attributePath := attribute
+			if path != "" {
+				attributePath = path + "." + attribute
+			}
+			collected = append(collected, pathValue{path: attributePath, value: value[attribute], attribute: true})
+			collected = collectPathValues(value[attribute], attributePath, collected)
+		}
+	case []interface{}:
+		for i, element := range value {
+			elementPath := path + "[" + strconv.Itoa(i) + "]"
+			collected = append(collected, pathValue{path: elementPath, value: element})
+			collected = collectPathValues(element, elementPath, collected)
+		}
+	}
+	return collected
+}
@@ -0,0 +1,191 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"testing"
+)
+
+const randomMutatorInput = `{
+	"name": "Perceval",
+	"questsAchieved": 0,
+	"knight": true,
+	"manager": {"name": "Arthur", "titles": ["King", "Suzerain"]},
+	"quests": []
+}`
+
+func TestRandomMutatorIsDeterministic(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		firstOutput, firstMutations, err := NewRandomMutator(seed).Mutate(randomMutatorInput, 3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		secondOutput, secondMutations, err := NewRandomMutator(seed).Mutate(randomMutatorInput, 3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if firstOutput != secondOutput {
+			t.Errorf("seed %d produced different outputs: [%v] and [%v]", seed, firstOutput, secondOutput)
+		}
+		for i := range firstMutations {
+			if firstMutations[i].String() != secondMutations[i].String() {
+				t.Errorf("seed %d produced different mutations: [%v] and [%v]", seed, firstMutations[i], secondMutations[i])
+			}
+		}
+	}
+}
+
+func TestRandomMutatorMutationsReproduceOutput(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		output, mutations, err := NewRandomMutator(seed).Mutate(randomMutatorInput, 4)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(mutations) != 4 {
+			t.Errorf("got %d mutations, wanted 4", len(mutations))
+		}
+		reproduced, err := Modify(randomMutatorInput, Modifications(mutations)...)
+		if err != nil {
+			t.Fatalf("seed %d: failed to apply mutations: %v", seed, err)
+		}
+		if reproduced != output {
+			t.Errorf("seed %d: mutations produced [%v], wanted [%v]", seed, reproduced, output)
+		}
+	}
+}
+
+func TestRandomMutatorKinds(t *testing.T) {
+	tests := map[string]struct {
+		kind          MutationKind
+		input         string
+		expectedPaths []string
+		check         func(value interface{}) bool
+	}{
+		"drop field": {
+			kind:          MutationDropField,
+			input:         `{"name": "Perceval", "titles": ["Knight"]}`,
+			expectedPaths: []string{"name", "titles"},
+			check:         func(value interface{}) bool { return value == nil },
+		},
+		"change type": {
+			kind:          MutationChangeType,
+			input:         `{"questsAchieved": 0}`,
+			expectedPaths: []string{"questsAchieved"},
+			check:         func(value interface{}) bool { return jsonTypeOf(value) != JSONNumber },
+		},
+		"boundary number": {
+			kind:          MutationBoundaryNumber,
+			input:         `{"name": "Perceval", "scores": [1, 2]}`,
+			expectedPaths: []string{"scores[0]", "scores[1]"},
+			check:         func(value interface{}) bool { return jsonTypeOf(value) == JSONNumber },
+		},
+		"long string": {
+			kind:          MutationLongString,
+			input:         `{"name": "Perceval", "questsAchieved": 0}`,
+			expectedPaths: []string{"name"},
+			check:         func(value interface{}) bool { return len(value.(string)) >= 256 },
+		},
+		"unicode string": {
+			kind:          MutationUnicodeString,
+			input:         `["Perceval"]`,
+			expectedPaths: []string{"[0]"},
+			check:         func(value interface{}) bool { return jsonTypeOf(value) == JSONString },
+		},
+		"duplicate array": {
+			kind:          MutationDuplicateArray,
+			input:         `{"titles": ["Knight", "Provençal"], "quests": []}`,
+			expectedPaths: []string{"titles"},
+			check:         func(value interface{}) bool { return len(value.([]interface{})) == 4 },
+		},
+		"empty array": {
+			kind:          MutationEmptyArray,
+			input:         `{"titles": ["Knight", "Provençal"], "quests": []}`,
+			expectedPaths: []string{"titles"},
+			check:         func(value interface{}) bool { return len(value.([]interface{})) == 0 },
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			for seed := int64(0); seed < 10; seed++ {
+				_, mutations, err := NewRandomMutator(seed, test.kind).Mutate(test.input, 1)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				mutation := mutations[0]
+				if mutation.Kind != test.kind {
+					t.Errorf("got kind [%v], wanted [%v]", mutation.Kind, test.kind)
+				}
+				if !containsString(test.expectedPaths, mutation.Path) {
+					t.Errorf("got path [%v], wanted one of %v", mutation.Path, test.expectedPaths)
+				}
+				if !test.check(mutation.Value) {
+					t.Errorf("unexpected value [%v]", mutation.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomMutatorErrors(t *testing.T) {
+	tests := map[string]struct {
+		input         string
+		kinds         []MutationKind
+		expectedError error
+	}{
+		"invalid json": {
+			input:         `{`,
+			expectedError: errors.New("unexpected end of JSON input"),
+		},
+		"nothing to mutate": {
+			input:         `"Perceval"`,
+			expectedError: errors.New("no mutation can be applied to the document"),
+		},
+		"no element of the right type": {
+			input:         `{"name": "Perceval"}`,
+			kinds:         []MutationKind{MutationBoundaryNumber, MutationEmptyArray},
+			expectedError: errors.New("no mutation can be applied to the document"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := NewRandomMutator(1, test.kinds...).Mutate(test.input, 1)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestMutationString(t *testing.T) {
+	tests := map[string]struct {
+		mutation       Mutation
+		expectedResult string
+	}{
+		"removal": {
+			mutation:       Mutation{Kind: MutationDropField, Path: "manager.name"},
+			expectedResult: `Remove("manager.name")`,
+		},
+		"setting a value": {
+			mutation:       Mutation{Kind: MutationChangeType, Path: "titles[0]", Value: map[string]interface{}{"fr": "Suzerain"}},
+			expectedResult: `Set("titles[0]", json.RawMessage("{\"fr\":\"Suzerain\"}"))`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if result := test.mutation.String(); result != test.expectedResult {
+				t.Errorf("got result [%v], wanted [%v]", result, test.expectedResult)
+			}
+		})
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
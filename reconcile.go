@@ -0,0 +1,150 @@
+package slowjsonmutator
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ModifyYAML, ModifyDialect and the binary formats convert their input to JSON data, apply modifications to it,
+// and then reconcile the nodes of the input with the modified data: each node is reconciled with the element
+// that replaces it, and kept as is when all its children were kept and its object members are the same.
+// Nodes are visited once, so that reconciling is linear in the size of the document.
+
+type memberState int
+
+const (
+	// memberRemoved is a member whose key the modified object no longer has
+	memberRemoved memberState = iota
+	// memberShadowed is a member followed by another with the same key, and kept as written
+	memberShadowed
+	// memberHeld is a member that holds the element of the modified object with its key
+	memberHeld
+)
+
+// planMembers matches the keys of the members of an object with value, the object once modified.
+// The last member with a given key holds the element of value, earlier ones are shadowed by it.
+// It also returns the attributes of value that no member holds, sorted, to append them as new members.
+func planMembers(keys []string, value map[string]interface{}) ([]memberState, []string) {
+	last := make(map[string]int, len(keys))
+	for i, key := range keys {
+		last[key] = i
+	}
+	states := make([]memberState, len(keys))
+	for i, key := range keys {
+		if _, ok := value[key]; !ok {
+			continue
+		}
+		if last[key] == i {
+			states[i] = memberHeld
+		} else {
+			states[i] = memberShadowed
+		}
+	}
+
+	var added []string
+	for attribute := range value {
+		if _, ok := last[attribute]; !ok {
+			added = append(added, attribute)
+		}
+	}
+	sort.Strings(added)
+	return states, added
+}
+
+// membersUnchanged tells whether planned members are exactly those of the original object
+func membersUnchanged(states []memberState, added []string) bool {
+	if len(added) != 0 {
+		return false
+	}
+	for _, state := range states {
+		if state == memberRemoved {
+			return false
+		}
+	}
+	return true
+}
+
+// isContainer tells whether value is a JSON object or array
+func isContainer(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// sameScalar tells whether the value of a scalar node is still value
+func sameScalar(original, value interface{}) bool {
+	return !isContainer(value) && reflect.DeepEqual(original, value)
+}
+
+// matchElements pairs the elements of values, an array once modified, with the elements of originals they come from.
+// Elements are paired from both ends as long as they are identical, which is what removing or inserting elements leaves;
+// the elements in between are paired by position when there are as many of them on both sides, as when they are replaced,
+// and when they are of the same kind, so that an original encoding never carries over to a value of another kind.
+// It returns, for each element of values, the index of its original element, or -1 for new elements.
+func matchElements(originals, values []interface{}) []int {
+	matches := make([]int, len(values))
+	for i := range matches {
+		matches[i] = -1
+	}
+	prefix := 0
+	for prefix < len(originals) && prefix < len(values) && identical(originals[prefix], values[prefix]) {
+		matches[prefix] = prefix
+		prefix++
+	}
+	suffix := 0
+	for prefix+suffix < len(originals) && prefix+suffix < len(values) &&
+		identical(originals[len(originals)-1-suffix], values[len(values)-1-suffix]) {
+		matches[len(values)-1-suffix] = len(originals) - 1 - suffix
+		suffix++
+	}
+	if len(originals) == len(values) {
+		for i := prefix; i < len(values)-suffix; i++ {
+			if jsonKind(originals[i]) == jsonKind(values[i]) {
+				matches[i] = i
+			}
+		}
+	}
+	return matches
+}
+
+// elementsUnchanged tells whether matched elements are exactly those of the original array, in the same order
+func elementsUnchanged(matches []int, originalCount int) bool {
+	if len(matches) != originalCount {
+		return false
+	}
+	for i, match := range matches {
+		if match != i {
+			return false
+		}
+	}
+	return true
+}
+
+// identical tells whether value is original: objects and arrays are compared by identity, which holds for
+// the elements that modifications left untouched since they never mutate their input, and scalars by value
+func identical(original, value interface{}) bool {
+	switch original := original.(type) {
+	case map[string]interface{}:
+		value, ok := value.(map[string]interface{})
+		return ok && reflect.ValueOf(original).Pointer() == reflect.ValueOf(value).Pointer()
+	case []interface{}:
+		value, ok := value.([]interface{})
+		return ok && len(original) == len(value) && (len(value) == 0 || &original[0] == &value[0])
+	default:
+		return sameScalar(original, value)
+	}
+}
+
+// jsonKind returns the kind of a JSON value, as a reflect.Kind: Map, Slice, String, Float64, Bool, or Invalid for null
+func jsonKind(value interface{}) reflect.Kind {
+	if value == nil {
+		return reflect.Invalid
+	}
+	if _, ok := toFloat64(value); ok {
+		return reflect.Float64
+	}
+	return reflect.TypeOf(value).Kind()
+}
@@ -0,0 +1,532 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSchemaDepth bounds how deeply schemas may be nested while validating, to stop on $ref cycles
+const maxSchemaDepth = 512
+
+// Schema is a parsed JSON Schema (draft 2020-12).
+// Only local references ($ref to "#..." JSON pointers or to a $anchor of the same document) are supported.
+// The format keyword is treated as an annotation, and unevaluatedProperties and unevaluatedItems are ignored.
+type Schema struct {
+	root     interface{}
+	anchors  map[string]interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// SchemaViolation is a reason why a document does not conform to a schema
+type SchemaViolation struct {
+	// Path is the path of the invalid element, using the same syntax as Set and Remove.
+	// It is empty when the whole document is invalid.
+	Path string
+	// Keyword is the schema keyword that failed
+	Keyword string
+	// Message explains the violation
+	Message string
+}
+
+// String formats the violation as "path: message"
+func (v SchemaViolation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return path + ": " + v.Message
+}
+
+// SchemaValidationError is returned by ValidateSchema when the document does not conform to the schema
+type SchemaValidationError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	lines := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		lines = append(lines, violation.String())
+	}
+	return "document does not conform to schema:\n- " + strings.Join(lines, "\n- ")
+}
+
+// ParseSchema parses a JSON Schema and checks that its references can be resolved
+func ParseSchema(schema string) (*Schema, error) {
+	var root interface{}
+	if err := json.Unmarshal([]byte(schema), &root); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	parsed := &Schema{
+		root:     root,
+		anchors:  map[string]interface{}{},
+		patterns: map[string]*regexp.Regexp{},
+	}
+	if err := parsed.index(root); err != nil {
+		return nil, err
+	}
+	if err := parsed.checkReferences(root); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// Validate checks whether document conforms to schema, and returns the violations if it does not
+func Validate(schema, document string) ([]SchemaViolation, error) {
+	parsedSchema, err := ParseSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	var parsedDocument interface{}
+	if err := json.Unmarshal([]byte(document), &parsedDocument); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+	return parsedSchema.Validate(parsedDocument), nil
+}
+
+// ValidateSchema returns a modification that leaves the document unchanged,
+// but fails with a *SchemaValidationError if it does not conform to schema
+func ValidateSchema(schema string) JSONModification {
+	parsedSchema, schemaErr := ParseSchema(schema)
+	return func(toModify interface{}) (interface{}, error) {
+		if schemaErr != nil {
+			return nil, schemaErr
+		}
+		if violations := parsedSchema.Validate(toModify); len(violations) != 0 {
+			return nil, &SchemaValidationError{Violations: violations}
+		}
+		return toModify, nil
+	}
+}
+
+// Validate checks whether untyped json data conforms to the schema, and returns the violations if it does not
+func (s *Schema) Validate(document interface{}) []SchemaViolation {
+	return s.validate(s.root, document, "", 0)
+}
+
+// index records the anchors of schema and compiles its patterns
+func (s *Schema) index(schema interface{}) error {
+	switch schema := schema.(type) {
+	case map[string]interface{}:
+		if anchor, ok := schema["$anchor"].(string); ok {
+			s.anchors[anchor] = schema
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if err := s.compilePattern(pattern); err != nil {
+				return err
+			}
+		}
+		if patternProperties, ok := schema["patternProperties"].(map[string]interface{}); ok {
+			for pattern := range patternProperties {
+				if err := s.compilePattern(pattern); err != nil {
+					return err
+				}
+			}
+		}
+		for _, subschema := range schema {
+			if err := s.index(subschema); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, subschema := range schema {
+			if err := s.index(subschema); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) compilePattern(pattern string) error {
+	if _, ok := s.patterns[pattern]; ok {
+		return nil
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("cannot compile schema pattern [%q]: %w", pattern, err)
+	}
+	s.patterns[pattern] = compiled
+	return nil
+}
+
+func (s *Schema) checkReferences(schema interface{}) error {
+	switch schema := schema.(type) {
+	case map[string]interface{}:
+		if reference, ok := schema["$ref"].(string); ok {
+			if _, err := s.resolve(reference); err != nil {
+				return err
+			}
+		}
+		for _, subschema := range schema {
+			if err := s.checkReferences(subschema); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, subschema := range schema {
+			if err := s.checkReferences(subschema); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolve returns the schema designated by a local reference
+func (s *Schema) resolve(reference string) (interface{}, error) {
+	if !strings.HasPrefix(reference, "#") {
+		return nil, fmt.Errorf("cannot resolve $ref [%q]: only local references are supported", reference)
+	}
+	fragment, err := url.PathUnescape(reference[1:])
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve $ref [%q]: %w", reference, err)
+	}
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		if anchored, ok := s.anchors[fragment]; ok {
+			return anchored, nil
+		}
+		return nil, fmt.Errorf("cannot resolve $ref [%q]: unknown anchor", reference)
+	}
+
+	current := s.root
+	if fragment == "" {
+		return current, nil
+	}
+	for _, token := range strings.Split(fragment[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch container := current.(type) {
+		case map[string]interface{}:
+			next, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("cannot resolve $ref [%q]: no attribute [%q]", reference, token)
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || len(container) <= index {
+				return nil, fmt.Errorf("cannot resolve $ref [%q]: no element [%q]", reference, token)
+			}
+			current = container[index]
+		default:
+			return nil, fmt.Errorf("cannot resolve $ref [%q]: [%q] is not inside an object or an array", reference, token)
+		}
+	}
+	return current, nil
+}
+
+func (s *Schema) validate(schema interface{}, instance interface{}, path string, depth int) []SchemaViolation {
+	switch schema := schema.(type) {
+	case bool:
+		if schema {
+			return nil
+		}
+		return []SchemaViolation{{Path: path, Keyword: "false", Message: "no value is allowed"}}
+	case map[string]interface{}:
+		if depth > maxSchemaDepth {
+			return []SchemaViolation{{Path: path, Keyword: "$ref", Message: "schema is too deeply nested, it probably has a reference cycle"}}
+		}
+		var violations []SchemaViolation
+		violations = append(violations, s.validateGeneric(schema, instance, path, depth)...)
+		violations = append(violations, s.validateApplicators(schema, instance, path, depth)...)
+		switch instance := instance.(type) {
+		case float64:
+			violations = append(violations, validateNumber(schema, instance, path)...)
+		case string:
+			violations = append(violations, s.validateString(schema, instance, path)...)
+		case []interface{}:
+			violations = append(violations, s.validateArray(schema, instance, path, depth)...)
+		case map[string]interface{}:
+			violations = append(violations, s.validateObject(schema, instance, path, depth)...)
+		}
+		return violations
+	default:
+		return nil
+	}
+}
+
+func (s *Schema) validateGeneric(schema map[string]interface{}, instance interface{}, path string, depth int) []SchemaViolation {
+	var violations []SchemaViolation
+	if expectedType, ok := schema["type"]; ok && !matchesSchemaType(expectedType, instance) {
+		violations = append(violations, SchemaViolation{
+			Path:    path,
+			Keyword: "type",
+			Message: fmt.Sprintf("expected %s, found %s", describeSchemaType(expectedType), jsonTypeOf(instance)),
+		})
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			found = found || reflect.DeepEqual(allowed, instance)
+		}
+		if !found {
+			violations = append(violations, SchemaViolation{Path: path, Keyword: "enum", Message: fmt.Sprintf("%s is not one of %s", compactJSON(instance), compactJSON(enum))})
+		}
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, instance) {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "const", Message: fmt.Sprintf("expected %s, found %s", compactJSON(constant), compactJSON(instance))})
+	}
+	if reference, ok := schema["$ref"].(string); ok {
+		if referenced, err := s.resolve(reference); err != nil {
+			violations = append(violations, SchemaViolation{Path: path, Keyword: "$ref", Message: err.Error()})
+		} else {
+			violations = append(violations, s.validate(referenced, instance, path, depth+1)...)
+		}
+	}
+	return violations
+}
+
+func (s *Schema) validateApplicators(schema map[string]interface{}, instance interface{}, path string, depth int) []SchemaViolation {
+	var violations []SchemaViolation
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, subschema := range allOf {
+			violations = append(violations, s.validate(subschema, instance, path, depth+1)...)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok && s.countMatches(anyOf, instance, path, depth) == 0 {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "anyOf", Message: "does not match any schema of anyOf"})
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		if matches := s.countMatches(oneOf, instance, path, depth); matches != 1 {
+			violations = append(violations, SchemaViolation{Path: path, Keyword: "oneOf", Message: fmt.Sprintf("matches %d schemas of oneOf, expected exactly one", matches)})
+		}
+	}
+	if not, ok := schema["not"]; ok && len(s.validate(not, instance, path, depth+1)) == 0 {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "not", Message: "matches the schema of not"})
+	}
+	if condition, ok := schema["if"]; ok {
+		if len(s.validate(condition, instance, path, depth+1)) == 0 {
+			if then, ok := schema["then"]; ok {
+				violations = append(violations, s.validate(then, instance, path, depth+1)...)
+			}
+		} else if otherwise, ok := schema["else"]; ok {
+			violations = append(violations, s.validate(otherwise, instance, path, depth+1)...)
+		}
+	}
+	return violations
+}
+
+func (s *Schema) countMatches(schemas []interface{}, instance interface{}, path string, depth int) int {
+	matches := 0
+	for _, subschema := range schemas {
+		if len(s.validate(subschema, instance, path, depth+1)) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+func validateNumber(schema map[string]interface{}, instance float64, path string) []SchemaViolation {
+	var violations []SchemaViolation
+	if multipleOf, ok := schema["multipleOf"].(float64); ok && multipleOf > 0 {
+		if quotient := instance / multipleOf; quotient != math.Trunc(quotient) {
+			violations = append(violations, SchemaViolation{Path: path, Keyword: "multipleOf", Message: fmt.Sprintf("%v is not a multiple of %v", instance, multipleOf)})
+		}
+	}
+	if maximum, ok := schema["maximum"].(float64); ok && instance > maximum {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "maximum", Message: fmt.Sprintf("%v is greater than %v", instance, maximum)})
+	}
+	if maximum, ok := schema["exclusiveMaximum"].(float64); ok && instance >= maximum {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "exclusiveMaximum", Message: fmt.Sprintf("%v is not less than %v", instance, maximum)})
+	}
+	if minimum, ok := schema["minimum"].(float64); ok && instance < minimum {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "minimum", Message: fmt.Sprintf("%v is less than %v", instance, minimum)})
+	}
+	if minimum, ok := schema["exclusiveMinimum"].(float64); ok && instance <= minimum {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "exclusiveMinimum", Message: fmt.Sprintf("%v is not greater than %v", instance, minimum)})
+	}
+	return violations
+}
+
+func (s *Schema) validateString(schema map[string]interface{}, instance string, path string) []SchemaViolation {
+	var violations []SchemaViolation
+	length := utf8.RuneCountInString(instance)
+	if maxLength, ok := schema["maxLength"].(float64); ok && float64(length) > maxLength {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "maxLength", Message: fmt.Sprintf("length %d is greater than %v", length, maxLength)})
+	}
+	if minLength, ok := schema["minLength"].(float64); ok && float64(length) < minLength {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "minLength", Message: fmt.Sprintf("length %d is less than %v", length, minLength)})
+	}
+	if pattern, ok := schema["pattern"].(string); ok && !s.patterns[pattern].MatchString(instance) {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "pattern", Message: fmt.Sprintf("%q does not match pattern %q", instance, pattern)})
+	}
+	return violations
+}
+
+func (s *Schema) validateArray(schema map[string]interface{}, instance []interface{}, path string, depth int) []SchemaViolation {
+	var violations []SchemaViolation
+	if maxItems, ok := schema["maxItems"].(float64); ok && float64(len(instance)) > maxItems {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "maxItems", Message: fmt.Sprintf("%d items is more than %v", len(instance), maxItems)})
+	}
+	if minItems, ok := schema["minItems"].(float64); ok && float64(len(instance)) < minItems {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "minItems", Message: fmt.Sprintf("%d items is less than %v", len(instance), minItems)})
+	}
+	if uniqueItems, ok := schema["uniqueItems"].(bool); ok && uniqueItems {
+		for i := range instance {
+			for j := i + 1; j < len(instance); j++ {
+				if reflect.DeepEqual(instance[i], instance[j]) {
+					violations = append(violations, SchemaViolation{Path: path, Keyword: "uniqueItems", Message: fmt.Sprintf("items %d and %d are equal", i, j)})
+				}
+			}
+		}
+	}
+
+	prefixItems, _ := schema["prefixItems"].([]interface{})
+	for i, element := range instance {
+		elementPath := path + "[" + strconv.Itoa(i) + "]"
+		if i < len(prefixItems) {
+			violations = append(violations, s.validate(prefixItems[i], element, elementPath, depth+1)...)
+		} else if items, ok := schema["items"]; ok {
+			violations = append(violations, s.validate(items, element, elementPath, depth+1)...)
+		}
+	}
+
+	if contains, ok := schema["contains"]; ok {
+		matches := 0
+		for i, element := range instance {
+			if len(s.validate(contains, element, path+"["+strconv.Itoa(i)+"]", depth+1)) == 0 {
+				matches++
+			}
+		}
+		minContains, hasMinContains := schema["minContains"].(float64)
+		if !hasMinContains {
+			minContains = 1
+		}
+		if float64(matches) < minContains {
+			violations = append(violations, SchemaViolation{Path: path, Keyword: "contains", Message: fmt.Sprintf("%d items match contains, expected at least %v", matches, minContains)})
+		}
+		if maxContains, ok := schema["maxContains"].(float64); ok && float64(matches) > maxContains {
+			violations = append(violations, SchemaViolation{Path: path, Keyword: "maxContains", Message: fmt.Sprintf("%d items match contains, expected at most %v", matches, maxContains)})
+		}
+	}
+	return violations
+}
+
+func (s *Schema) validateObject(schema map[string]interface{}, instance map[string]interface{}, path string, depth int) []SchemaViolation {
+	var violations []SchemaViolation
+	if maxProperties, ok := schema["maxProperties"].(float64); ok && float64(len(instance)) > maxProperties {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "maxProperties", Message: fmt.Sprintf("%d properties is more than %v", len(instance), maxProperties)})
+	}
+	if minProperties, ok := schema["minProperties"].(float64); ok && float64(len(instance)) < minProperties {
+		violations = append(violations, SchemaViolation{Path: path, Keyword: "minProperties", Message: fmt.Sprintf("%d properties is less than %v", len(instance), minProperties)})
+	}
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, attribute := range required {
+			if attribute, ok := attribute.(string); ok {
+				if _, present := instance[attribute]; !present {
+					violations = append(violations, SchemaViolation{Path: path, Keyword: "required", Message: fmt.Sprintf("missing required property %q", attribute)})
+				}
+			}
+		}
+	}
+	if dependentRequired, ok := schema["dependentRequired"].(map[string]interface{}); ok {
+		for _, attribute := range sortedAttributes(dependentRequired) {
+			if _, present := instance[attribute]; !present {
+				continue
+			}
+			dependencies, _ := dependentRequired[attribute].([]interface{})
+			for _, dependency := range dependencies {
+				if dependency, ok := dependency.(string); ok {
+					if _, present := instance[dependency]; !present {
+						violations = append(violations, SchemaViolation{Path: path, Keyword: "dependentRequired", Message: fmt.Sprintf("missing property %q, required by %q", dependency, attribute)})
+					}
+				}
+			}
+		}
+	}
+	if dependentSchemas, ok := schema["dependentSchemas"].(map[string]interface{}); ok {
+		for _, attribute := range sortedAttributes(dependentSchemas) {
+			if _, present := instance[attribute]; present {
+				violations = append(violations, s.validate(dependentSchemas[attribute], instance, path, depth+1)...)
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+	additionalProperties, hasAdditionalProperties := schema["additionalProperties"]
+	propertyNames, hasPropertyNames := schema["propertyNames"]
+	for _, attribute := range sortedAttributes(instance) {
+		attributePath := attribute
+		if path != "" {
+			attributePath = path + "." + attribute
+		}
+		value := instance[attribute]
+
+		if hasPropertyNames && len(s.validate(propertyNames, attribute, attributePath, depth+1)) != 0 {
+			violations = append(violations, SchemaViolation{Path: attributePath, Keyword: "propertyNames", Message: fmt.Sprintf("property name %q does not match propertyNames", attribute)})
+		}
+
+		evaluated := false
+		if propertySchema, ok := properties[attribute]; ok {
+			evaluated = true
+			violations = append(violations, s.validate(propertySchema, value, attributePath, depth+1)...)
+		}
+		for _, pattern := range sortedAttributes(patternProperties) {
+			if s.patterns[pattern].MatchString(attribute) {
+				evaluated = true
+				violations = append(violations, s.validate(patternProperties[pattern], value, attributePath, depth+1)...)
+			}
+		}
+		if !evaluated && hasAdditionalProperties {
+			if additionalProperties == false {
+				violations = append(violations, SchemaViolation{Path: attributePath, Keyword: "additionalProperties", Message: fmt.Sprintf("property %q is not allowed", attribute)})
+			} else {
+				violations = append(violations, s.validate(additionalProperties, value, attributePath, depth+1)...)
+			}
+		}
+	}
+	return violations
+}
+
+func matchesSchemaType(expectedType interface{}, instance interface{}) bool {
+	switch expectedType := expectedType.(type) {
+	case string:
+		actualType := jsonTypeOf(instance)
+		switch expectedType {
+		case "integer":
+			number, ok := instance.(float64)
+			return ok && number == math.Trunc(number) && !math.IsInf(number, 0)
+		case "boolean", "null", "number", "string", "array", "object":
+			return string(actualType) == expectedType
+		default:
+			return false
+		}
+	case []interface{}:
+		for _, candidate := range expectedType {
+			if matchesSchemaType(candidate, instance) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func describeSchemaType(expectedType interface{}) string {
+	if types, ok := expectedType.([]interface{}); ok {
+		names := make([]string, 0, len(types))
+		for _, name := range types {
+			names = append(names, fmt.Sprintf("%v", name))
+		}
+		return "one of " + strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%v", expectedType)
+}
+
+func sortedAttributes(object map[string]interface{}) []string {
+	attributes := make([]string, 0, len(object))
+	for attribute := range object {
+		attributes = append(attributes, attribute)
+	}
+	sort.Strings(attributes)
+	return attributes
+}
@@ -0,0 +1,242 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+const knightSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["name", "rank"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 2, "maxLength": 20},
+		"rank": {"enum": ["knight", "king"]},
+		"questsAchieved": {"type": "integer", "minimum": 0, "maximum": 100},
+		"email": {"type": "string", "pattern": "^[a-z]+@kaamelott\\.fr$"},
+		"titles": {"type": "array", "items": {"type": "string"}, "minItems": 1, "uniqueItems": true},
+		"manager": {"$ref": "#/$defs/person"}
+	},
+	"$defs": {
+		"person": {
+			"type": ["object", "null"],
+			"required": ["name"],
+			"properties": {
+				"name": {"type": "string"},
+				"manager": {"$ref": "#/$defs/person"}
+			}
+		}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		schema             string
+		document           string
+		expectedViolations []SchemaViolation
+		expectedError      error
+	}{
+		"valid document": {
+			schema:   knightSchema,
+			document: `{"name": "Perceval", "rank": "knight", "questsAchieved": 0, "titles": ["Le Gaulois"], "manager": {"name": "Arthur", "manager": null}}`,
+		},
+		"missing required properties and additional property": {
+			schema:   knightSchema,
+			document: `{"name": "Perceval", "aka": "Provençal"}`,
+			expectedViolations: []SchemaViolation{
+				{Path: "", Keyword: "required", Message: `missing required property "rank"`},
+				{Path: "aka", Keyword: "additionalProperties", Message: `property "aka" is not allowed`},
+			},
+		},
+		"invalid values": {
+			schema:   knightSchema,
+			document: `{"name": "P", "rank": "squire", "questsAchieved": 1.5, "email": "perceval@gaule.fr", "titles": ["A", "A"]}`,
+			expectedViolations: []SchemaViolation{
+				{Path: "email", Keyword: "pattern", Message: `"perceval@gaule.fr" does not match pattern "^[a-z]+@kaamelott\\.fr$"`},
+				{Path: "name", Keyword: "minLength", Message: "length 1 is less than 2"},
+				{Path: "questsAchieved", Keyword: "type", Message: "expected integer, found number"},
+				{Path: "rank", Keyword: "enum", Message: `"squire" is not one of ["knight","king"]`},
+				{Path: "titles", Keyword: "uniqueItems", Message: "items 0 and 1 are equal"},
+			},
+		},
+		"invalid nested values through references": {
+			schema:   knightSchema,
+			document: `{"name": "Perceval", "rank": "knight", "titles": [3], "manager": {"manager": {"name": 1}}}`,
+			expectedViolations: []SchemaViolation{
+				{Path: "manager", Keyword: "required", Message: `missing required property "name"`},
+				{Path: "manager.manager.name", Keyword: "type", Message: "expected string, found number"},
+				{Path: "titles[0]", Keyword: "type", Message: "expected string, found number"},
+			},
+		},
+		"numeric bounds": {
+			schema:   `{"items": {"exclusiveMinimum": 0, "exclusiveMaximum": 10, "multipleOf": 2}, "maxItems": 2}`,
+			document: `[0, 10, 3]`,
+			expectedViolations: []SchemaViolation{
+				{Path: "", Keyword: "maxItems", Message: "3 items is more than 2"},
+				{Path: "[0]", Keyword: "exclusiveMinimum", Message: "0 is not greater than 0"},
+				{Path: "[1]", Keyword: "exclusiveMaximum", Message: "10 is not less than 10"},
+				{Path: "[2]", Keyword: "multipleOf", Message: "3 is not a multiple of 2"},
+			},
+		},
+		"combinators": {
+			schema: `{
+				"properties": {
+					"anyOf": {"anyOf": [{"type": "string"}, {"type": "number"}]},
+					"oneOf": {"oneOf": [{"minimum": 0}, {"maximum": 10}]},
+					"not": {"not": {"const": "Karadoc"}},
+					"conditional": {"if": {"type": "string"}, "then": {"minLength": 3}, "else": {"type": "number"}},
+					"never": false
+				}
+			}`,
+			document: `{"anyOf": true, "oneOf": 5, "not": "Karadoc", "conditional": "ab", "never": null}`,
+			expectedViolations: []SchemaViolation{
+				{Path: "anyOf", Keyword: "anyOf", Message: "does not match any schema of anyOf"},
+				{Path: "conditional", Keyword: "minLength", Message: "length 2 is less than 3"},
+				{Path: "never", Keyword: "false", Message: "no value is allowed"},
+				{Path: "not", Keyword: "not", Message: "matches the schema of not"},
+				{Path: "oneOf", Keyword: "oneOf", Message: "matches 2 schemas of oneOf, expected exactly one"},
+			},
+		},
+		"array keywords": {
+			schema:   `{"prefixItems": [{"type": "string"}], "items": {"type": "number"}, "contains": {"const": 1}, "maxContains": 1}`,
+			document: `[1, 1, 1]`,
+			expectedViolations: []SchemaViolation{
+				{Path: "", Keyword: "maxContains", Message: "3 items match contains, expected at most 1"},
+				{Path: "[0]", Keyword: "type", Message: "expected string, found number"},
+			},
+		},
+		"object keywords": {
+			schema: `{
+				"minProperties": 4,
+				"propertyNames": {"maxLength": 5},
+				"patternProperties": {"^x-": {"type": "string"}},
+				"dependentRequired": {"email": ["name"]},
+				"dependentSchemas": {"name": {"required": ["rank"]}},
+				"additionalProperties": {"type": "boolean"}
+			}`,
+			document: `{"x-id": 1, "email": true, "name": true}`,
+			expectedViolations: []SchemaViolation{
+				{Path: "", Keyword: "minProperties", Message: "3 properties is less than 4"},
+				{Path: "", Keyword: "required", Message: `missing required property "rank"`},
+				{Path: "x-id", Keyword: "type", Message: "expected string, found number"},
+			},
+		},
+		"anchors": {
+			schema:   `{"$defs": {"name": {"$anchor": "name", "type": "string"}}, "items": {"$ref": "#name"}}`,
+			document: `["Perceval", null]`,
+			expectedViolations: []SchemaViolation{
+				{Path: "[1]", Keyword: "type", Message: "expected string, found null"},
+			},
+		},
+		"reference cycle": {
+			schema:   `{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`,
+			document: `1`,
+			expectedViolations: []SchemaViolation{
+				{Path: "", Keyword: "$ref", Message: "schema is too deeply nested, it probably has a reference cycle"},
+			},
+		},
+		"remote reference": {
+			schema:        `{"$ref": "https://example.com/schema.json"}`,
+			document:      `{}`,
+			expectedError: errors.New(`cannot resolve $ref ["https://example.com/schema.json"]: only local references are supported`),
+		},
+		"unresolvable reference": {
+			schema:        `{"$ref": "#/$defs/missing"}`,
+			document:      `{}`,
+			expectedError: errors.New(`cannot resolve $ref ["#/$defs/missing"]: no attribute ["$defs"]`),
+		},
+		"invalid pattern": {
+			schema:        `{"pattern": "("}`,
+			document:      `{}`,
+			expectedError: errors.New("cannot compile schema pattern [\"(\"]: error parsing regexp: missing closing ): `(`"),
+		},
+		"invalid schema": {
+			schema:        `{`,
+			document:      `{}`,
+			expectedError: errors.New("failed to parse schema: unexpected end of JSON input"),
+		},
+		"invalid document": {
+			schema:        `true`,
+			document:      `{`,
+			expectedError: errors.New("failed to parse document: unexpected end of JSON input"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			violations, err := Validate(test.schema, test.document)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if diff := DeepEqual(sortViolations(violations), test.expectedViolations); diff != "" {
+				t.Errorf("unexpected violations: " + diff)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaModification(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		modifications  []JSONModification
+		expectedOutput string
+		expectedError  error
+	}{
+		"modified document still conforms": {
+			input: `{"name": "Perceval", "rank": "knight"}`,
+			modifications: []JSONModification{
+				Set("questsAchieved", 3),
+				ValidateSchema(knightSchema),
+			},
+			expectedOutput: `{"name": "Perceval", "rank": "knight", "questsAchieved": 3}`,
+		},
+		"modified document does not conform anymore": {
+			input: `{"name": "Perceval", "rank": "knight"}`,
+			modifications: []JSONModification{
+				Remove("rank"),
+				Set("manager.name", 3),
+				ValidateSchema(knightSchema),
+			},
+			expectedError: errors.New(`document does not conform to schema:
+- (root): missing required property "rank"
+- manager.name: expected string, found number`),
+		},
+		"invalid schema": {
+			input: `{}`,
+			modifications: []JSONModification{
+				ValidateSchema(`[`),
+			},
+			expectedError: errors.New("failed to parse schema: unexpected end of JSON input"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := Modify(test.input, test.modifications...)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if output == test.expectedOutput {
+				return
+			}
+			if message, ok := JSONEqual(output, test.expectedOutput); !ok {
+				t.Error("unexpected output: " + message)
+			}
+		})
+	}
+}
+
+// sortViolations sorts violations by path then keyword, since the order of violations of different keywords is not specified
+func sortViolations(violations []SchemaViolation) []SchemaViolation {
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Path != violations[j].Path {
+			return violations[i].Path < violations[j].Path
+		}
+		return violations[i].Keyword < violations[j].Keyword
+	})
+	return violations
+}
@@ -0,0 +1,119 @@
+package slowjsonmutator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// wildcardIndex is the path segment that addresses every element of an array in Scoped prefixes
+const wildcardIndex = "[*]"
+
+// Chain groups modifications into a single one, so that they can be reused as a preset
+func Chain(modifications ...JSONModification) JSONModification {
+	return func(toModify interface{}) (interface{}, error) {
+		return applyModifications(toModify, modifications)
+	}
+}
+
+// Scoped applies modifications to the element at prefix, as if it were the whole document:
+// paths used by modifications are relative to prefix.
+// The prefix may contain [*] segments, in which case modifications are applied to every element of the array.
+// An element missing at prefix is created only if modifications produce something other than null.
+func Scoped(prefix string, modifications ...JSONModification) JSONModification {
+	return func(toModify interface{}) (interface{}, error) {
+		scopes, err := parseScopePath(prefix)
+		if err != nil {
+			return nil, err
+		}
+		return applyScoped(toModify, scopes, prefix, modifications)
+	}
+}
+
+// ForEach applies modifications to each element of the array at arrayPath, with paths relative to the element.
+// An empty arrayPath designates the whole document. Nothing is done if there is no element at arrayPath.
+func ForEach(arrayPath string, modifications ...JSONModification) JSONModification {
+	return func(toModify interface{}) (interface{}, error) {
+		pathSegments, err := parseJSONPathOrRoot(arrayPath)
+		if err != nil {
+			return nil, err
+		}
+		array, found := get(toModify, pathSegments)
+		if !found {
+			return toModify, nil
+		}
+		modifiedArray, err := forEachElement(array, arrayPath, Chain(modifications...))
+		if err != nil {
+			return nil, err
+		}
+		return set(toModify, pathSegments, modifiedArray)
+	}
+}
+
+// ForEachWhere applies modifications to each element of the array at arrayPath for which predicate holds.
+// Both predicate and modifications use paths relative to the element.
+func ForEachWhere(arrayPath string, predicate JSONPredicate, modifications ...JSONModification) JSONModification {
+	return ForEach(arrayPath, When(predicate, modifications...))
+}
+
+// parseScopePath splits a path on its wildcard segments, and parses what is between them
+func parseScopePath(path string) ([][]jsonPathSegment, error) {
+	parts := strings.Split(path, wildcardIndex)
+	scopes := make([][]jsonPathSegment, 0, len(parts))
+	for i, part := range parts {
+		if part == "" {
+			scopes = append(scopes, nil)
+			continue
+		}
+		if i > 0 && part[0] != '.' && part[0] != '[' {
+			return nil, fmt.Errorf("cannot parse json path [%q], it doesn't seem valid", path)
+		}
+		pathSegments, err := parseJSONPath(strings.TrimPrefix(part, "."))
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse json path [%q], it doesn't seem valid", path)
+		}
+		scopes = append(scopes, pathSegments)
+	}
+	return scopes, nil
+}
+
+func applyScoped(toModify interface{}, scopes [][]jsonPathSegment, path string, modifications []JSONModification) (interface{}, error) {
+	scoped, found := get(toModify, scopes[0])
+
+	var modifiedScoped interface{}
+	var err error
+	if len(scopes) == 1 {
+		modifiedScoped, err = applyModifications(scoped, modifications)
+	} else {
+		modifiedScoped, err = forEachElement(scoped, path, func(element interface{}) (interface{}, error) {
+			return applyScoped(element, scopes[1:], path, modifications)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !found && modifiedScoped == nil {
+		return toModify, nil
+	}
+	return set(toModify, scopes[0], modifiedScoped)
+}
+
+// forEachElement replaces each element of array by the result of modification
+func forEachElement(array interface{}, path string, modification JSONModification) (interface{}, error) {
+	switch array := array.(type) {
+	case []interface{}:
+		modified := make([]interface{}, len(array))
+		for i, element := range array {
+			modifiedElement, err := modification(element)
+			if err != nil {
+				return nil, fmt.Errorf("cannot modify element %d of array at path [%q]: %w", i, path, err)
+			}
+			modified[i] = modifiedElement
+		}
+		return modified, nil
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("cannot iterate over element at path [%q], it is not a JSON array", path)
+	}
+}
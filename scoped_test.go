@@ -0,0 +1,172 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScopedModifications(t *testing.T) {
+	anonymizeUser := Chain(
+		Set("name", "Anonymous"),
+		Remove("email"),
+	)
+
+	tests := map[string]struct {
+		input          string
+		modifications  []JSONModification
+		expectedOutput string
+		expectedError  error
+	}{
+		"chain at top level": {
+			input: `{"name": "Perceval", "email": "perceval@kaamelott.fr"}`,
+			modifications: []JSONModification{
+				anonymizeUser,
+			},
+			expectedOutput: `{"name": "Anonymous"}`,
+		},
+		"empty chain": {
+			input: `{"name": "Perceval"}`,
+			modifications: []JSONModification{
+				Chain(),
+			},
+			expectedOutput: `{"name": "Perceval"}`,
+		},
+		"scoped preset": {
+			input: `{"order": {"id": 1, "customer": {"name": "Perceval", "email": "perceval@kaamelott.fr"}}}`,
+			modifications: []JSONModification{
+				Scoped("order.customer", anonymizeUser),
+			},
+			expectedOutput: `{"order": {"id": 1, "customer": {"name": "Anonymous"}}}`,
+		},
+		"scoped preset on a missing element": {
+			input: `{}`,
+			modifications: []JSONModification{
+				Scoped("order.customer", anonymizeUser),
+			},
+			expectedOutput: `{"order": {"customer": {"name": "Anonymous"}}}`,
+		},
+		"scoped preset that does not create anything on a missing element": {
+			input: `{}`,
+			modifications: []JSONModification{
+				Scoped("order.customer", Remove("email")),
+			},
+			expectedOutput: `{}`,
+		},
+		"scoped preset on each element of an array": {
+			input: `{"orders": [
+				{"customer": {"name": "Perceval", "email": "perceval@kaamelott.fr"}},
+				{"customer": {"name": "Karadoc", "email": "karadoc@kaamelott.fr"}}
+			]}`,
+			modifications: []JSONModification{
+				Scoped("orders[*].customer", anonymizeUser),
+			},
+			expectedOutput: `{"orders": [
+				{"customer": {"name": "Anonymous"}},
+				{"customer": {"name": "Anonymous"}}
+			]}`,
+		},
+		"scoped preset on nested arrays": {
+			input: `[[{"name": "Perceval"}], [{"name": "Karadoc"}, {"name": "Lancelot"}]]`,
+			modifications: []JSONModification{
+				Scoped("[*][*]", Set("title", "Knight")),
+			},
+			expectedOutput: `[
+				[{"name": "Perceval", "title": "Knight"}],
+				[{"name": "Karadoc", "title": "Knight"}, {"name": "Lancelot", "title": "Knight"}]
+			]`,
+		},
+		"nested scopes": {
+			input: `{"order": {"customer": {"address": {"city": "Kaamelott"}}}}`,
+			modifications: []JSONModification{
+				Scoped("order", Scoped("customer.address", Set("city", "Carmélide"))),
+			},
+			expectedOutput: `{"order": {"customer": {"address": {"city": "Carmélide"}}}}`,
+		},
+		"scoped with a wildcard on something that is not an array": {
+			input: `{"orders": {}}`,
+			modifications: []JSONModification{
+				Scoped("orders[*].customer", anonymizeUser),
+			},
+			expectedError: errors.New(`cannot iterate over element at path ["orders[*].customer"], it is not a JSON array`),
+		},
+		"scoped error reports the element index": {
+			input: `{"orders": [{"customer": {}}, {"customer": "Perceval"}]}`,
+			modifications: []JSONModification{
+				Scoped("orders[*].customer", anonymizeUser),
+			},
+			expectedError: errors.New(`cannot modify element 1 of array at path ["orders[*].customer"]: invalid path`),
+		},
+		"scoped with an invalid prefix": {
+			input: `{}`,
+			modifications: []JSONModification{
+				Scoped("orders[*]customer", anonymizeUser),
+			},
+			expectedError: errors.New(`cannot parse json path ["orders[*]customer"], it doesn't seem valid`),
+		},
+		"for each element of an array": {
+			input: `{"knights": [{"name": "Perceval"}, {"name": "Karadoc"}]}`,
+			modifications: []JSONModification{
+				ForEach("knights", Set("title", "Knight"), Remove("name")),
+			},
+			expectedOutput: `{"knights": [{"title": "Knight"}, {"title": "Knight"}]}`,
+		},
+		"for each element of a top-level array": {
+			input: `[{"name": "Perceval"}, {"name": "Karadoc"}]`,
+			modifications: []JSONModification{
+				ForEach("", Set("title", "Knight")),
+			},
+			expectedOutput: `[{"name": "Perceval", "title": "Knight"}, {"name": "Karadoc", "title": "Knight"}]`,
+		},
+		"for each element of a missing array": {
+			input: `{}`,
+			modifications: []JSONModification{
+				ForEach("knights", Set("title", "Knight")),
+			},
+			expectedOutput: `{}`,
+		},
+		"for each element matching a predicate": {
+			input: `{"knights": [{"name": "Perceval", "tier": "gold"}, {"name": "Karadoc", "tier": "silver"}]}`,
+			modifications: []JSONModification{
+				ForEachWhere("knights", Equals("tier", "gold"), Set("discount", 10)),
+			},
+			expectedOutput: `{"knights": [{"name": "Perceval", "tier": "gold", "discount": 10}, {"name": "Karadoc", "tier": "silver"}]}`,
+		},
+		"for each element of nested arrays": {
+			input: `{"tables": [{"knights": [{"name": "Perceval"}]}, {"knights": [{"name": "Karadoc"}]}]}`,
+			modifications: []JSONModification{
+				ForEach("tables", ForEach("knights", Set("seated", true))),
+			},
+			expectedOutput: `{"tables": [{"knights": [{"name": "Perceval", "seated": true}]}, {"knights": [{"name": "Karadoc", "seated": true}]}]}`,
+		},
+		"for each error reports the element index": {
+			input: `{"knights": [{"name": "Perceval"}, "Karadoc"]}`,
+			modifications: []JSONModification{
+				ForEach("knights", Set("title", "Knight")),
+			},
+			expectedError: errors.New(`cannot modify element 1 of array at path ["knights"]: invalid path`),
+		},
+		"for each on something that is not an array": {
+			input: `{"knights": "Perceval"}`,
+			modifications: []JSONModification{
+				ForEach("knights", Set("title", "Knight")),
+			},
+			expectedError: errors.New(`cannot iterate over element at path ["knights"], it is not a JSON array`),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := Modify(test.input, test.modifications...)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if output == test.expectedOutput {
+				return
+			}
+			if message, ok := JSONEqual(output, test.expectedOutput); !ok {
+				t.Error("unexpected output: " + message)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package sjmtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sjm "github.com/remieven/slowjsonmutator-go"
+)
+
+// update is namespaced so that it does not clash with the -update flag that packages often define themselves
+var update = flag.Bool("sjmtest.update", false, "rewrite golden files of sjmtest.AssertGolden with the actual output")
+
+// goldenDirectory is where golden files are stored, relative to the package being tested
+var goldenDirectory = "testdata"
+
+// MaskedValue is the value that Mask sets
+const MaskedValue = "<masked>"
+
+// Mask returns a modification that replaces the element at the given path by MaskedValue, if it exists.
+// It is meant to hide volatile values, such as identifiers or timestamps, before comparing documents.
+func Mask(path string) sjm.JSONModification {
+	return sjm.When(sjm.Exists(path), sjm.Set(path, MaskedValue))
+}
+
+// AssertGolden checks that got matches the content of testdata/<name>.golden.json,
+// after applying masks to both of them.
+// When tests are run with the -sjmtest.update flag, the golden file is rewritten with got, masked,
+// pretty-printed and with sorted attributes, and the assertion passes.
+func AssertGolden(t TestingT, name string, got string, masks ...sjm.JSONModification) bool {
+	t.Helper()
+
+	maskedGot, err := sjm.Modify(got, masks...)
+	if err != nil {
+		t.Errorf("failed to mask got json: %v", err)
+		return false
+	}
+
+	goldenPath := filepath.Join(goldenDirectory, name+".golden.json")
+	if *update {
+		if err := writeGolden(goldenPath, maskedGot); err != nil {
+			t.Errorf("failed to update golden file: %v", err)
+			return false
+		}
+		return true
+	}
+
+	golden, err := os.ReadFile(goldenPath)
+	if os.IsNotExist(err) {
+		t.Errorf("golden file %s does not exist, run tests with -sjmtest.update to create it", goldenPath)
+		return false
+	} else if err != nil {
+		t.Errorf("failed to read golden file: %v", err)
+		return false
+	}
+	maskedGolden, err := sjm.Modify(string(golden), masks...)
+	if err != nil {
+		t.Errorf("failed to mask golden file %s: %v", goldenPath, err)
+		return false
+	}
+
+	changes, err := compare(maskedGot, maskedGolden)
+	if err != nil {
+		t.Errorf("%v", err)
+		return false
+	}
+	if len(changes) == 0 {
+		return true
+	}
+	lines := make([]string, 0, len(changes))
+	for _, change := range changes {
+		lines = append(lines, change.String())
+	}
+	t.Errorf("JSON contents do not match golden file %s, changes from golden to got:\n%s", goldenPath, strings.Join(lines, "\n"))
+	return false
+}
+
+// writeGolden writes document to path, indented and with sorted attributes
+func writeGolden(path string, document string) error {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(document), &parsed); err != nil {
+		return err
+	}
+	var indented bytes.Buffer
+	encoder := json.NewEncoder(&indented)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(parsed); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, indented.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package sjmtest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sjm "github.com/remieven/slowjsonmutator-go"
+)
+
+func TestAssertGolden(t *testing.T) {
+	tests := map[string]struct {
+		golden          string
+		got             string
+		masks           []sjm.JSONModification
+		expectedMessage string
+	}{
+		"matching golden file": {
+			golden: `{"name": "Perceval", "titles": ["Knight"]}`,
+			got:    `{"titles": ["Knight"], "name": "Perceval"}`,
+		},
+		"matching golden file once masked": {
+			golden: `{"id": "<masked>", "name": "Perceval", "knights": [{"id": 1}]}`,
+			got:    `{"id": "7b3c", "name": "Perceval", "knights": [{"id": 2}]}`,
+			masks:  []sjm.JSONModification{Mask("id"), sjm.ForEach("knights", Mask("id")), Mask("missing")},
+		},
+		"golden file not matching": {
+			golden: `{"id": "1", "name": "Karadoc"}`,
+			got:    `{"id": "2", "name": "Perceval"}`,
+			masks:  []sjm.JSONModification{Mask("id")},
+			expectedMessage: `JSON contents do not match golden file testdata/matching.golden.json, changes from golden to got:
+~ name: "Karadoc" -> "Perceval"`,
+		},
+		"invalid golden file": {
+			golden:          `{`,
+			got:             `{}`,
+			expectedMessage: "failed to mask golden file testdata/matching.golden.json: unexpected end of JSON input",
+		},
+		"invalid got": {
+			golden:          `{}`,
+			got:             `{`,
+			expectedMessage: "failed to mask got json: unexpected end of JSON input",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			directory := t.TempDir()
+			if err := os.WriteFile(filepath.Join(directory, "matching.golden.json"), []byte(test.golden), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			withGoldenDirectory(t, directory)
+
+			recorder := &recordingT{}
+			result := AssertGolden(recorder, "matching", test.got, test.masks...)
+
+			if result != (test.expectedMessage == "") {
+				t.Errorf("got result [%v], wanted [%v]", result, test.expectedMessage == "")
+			}
+			var message string
+			if len(recorder.messages) != 0 {
+				message = strings.ReplaceAll(recorder.messages[0], directory, "testdata")
+			}
+			if message != test.expectedMessage {
+				t.Errorf("got message [%v], wanted [%v]", message, test.expectedMessage)
+			}
+		})
+	}
+}
+
+func TestAssertGoldenMissingFile(t *testing.T) {
+	directory := t.TempDir()
+	withGoldenDirectory(t, directory)
+
+	recorder := &recordingT{}
+	if AssertGolden(recorder, "missing", `{}`) {
+		t.Error("assertion should have failed")
+	}
+	expectedMessage := "golden file testdata/missing.golden.json does not exist, run tests with -sjmtest.update to create it"
+	if len(recorder.messages) != 1 || strings.ReplaceAll(recorder.messages[0], directory, "testdata") != expectedMessage {
+		t.Errorf("got messages %q, wanted [%q]", recorder.messages, expectedMessage)
+	}
+}
+
+// consumerUpdate is defined as packages using sjmtest often do: registering it must not panic
+var consumerUpdate = flag.Bool("update", false, "update golden files of the consumer")
+
+func TestAssertGoldenFlagDoesNotClash(t *testing.T) {
+	if flag.Lookup("update").Usage != "update golden files of the consumer" {
+		t.Errorf("expected the -update flag to be the one of the consumer")
+	}
+	*consumerUpdate = true
+	defer func() { *consumerUpdate = false }()
+
+	directory := t.TempDir()
+	withGoldenDirectory(t, directory)
+	recorder := &recordingT{}
+	if AssertGolden(recorder, "missing", `{"name": "Perceval"}`) {
+		t.Error("the -update flag of the consumer should not update golden files")
+	}
+	if _, err := os.Stat(filepath.Join(directory, "missing.golden.json")); !os.IsNotExist(err) {
+		t.Errorf("unexpected error: wanted [not exist], got [%v]", err)
+	}
+}
+
+func TestAssertGoldenUpdate(t *testing.T) {
+	directory := t.TempDir()
+	withGoldenDirectory(t, directory)
+	*update = true
+	defer func() { *update = false }()
+
+	recorder := &recordingT{}
+	if !AssertGolden(recorder, "handlers/knight", `{"name": "Perceval", "id": 42, "manager": {"title": "King", "name": "Arthur"}}`, Mask("id")) {
+		t.Errorf("assertion should have passed, got messages %q", recorder.messages)
+	}
+
+	written, err := os.ReadFile(filepath.Join(directory, "handlers", "knight.golden.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedContent := `{
+  "id": "<masked>",
+  "manager": {
+    "name": "Arthur",
+    "title": "King"
+  },
+  "name": "Perceval"
+}
+`
+	if string(written) != expectedContent {
+		t.Errorf("got golden file [%v], wanted [%v]", string(written), expectedContent)
+	}
+}
+
+// withGoldenDirectory makes AssertGolden use directory instead of testdata for the rest of the test
+func withGoldenDirectory(t *testing.T, directory string) {
+	previousGoldenDirectory := goldenDirectory
+	goldenDirectory = directory
+	t.Cleanup(func() { goldenDirectory = previousGoldenDirectory })
+}
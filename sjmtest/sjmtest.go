@@ -0,0 +1,260 @@
+// Package sjmtest provides test assertions on JSON documents, reporting differences
+// with the paths used by slowjsonmutator.
+package sjmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	sjm "github.com/remieven/slowjsonmutator-go"
+)
+
+// TestingT is the subset of testing.TB used by assertions
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Option customizes how JSON documents are compared
+type Option func(*comparison)
+
+type comparison struct {
+	ignoredPaths          [][]string
+	unorderedArrayPaths   [][]string
+	numericTolerance      float64
+	invalidPatternMessage string
+}
+
+// IgnorePaths ignores the elements whose path matches one of patterns.
+// Patterns use the path syntax of slowjsonmutator, where * matches any attribute and [*] any index,
+// so that "meta.*" ignores every attribute of meta and "knights[*].id" the id of every knight.
+// Objects and arrays left empty because all their elements are ignored are ignored as well,
+// so that "meta.*" also ignores meta itself when it is missing from the other document.
+func IgnorePaths(patterns ...string) Option {
+	return func(c *comparison) {
+		c.ignoredPaths = append(c.ignoredPaths, c.parsePatterns(patterns)...)
+	}
+}
+
+// IgnoreArrayOrder ignores the order of the elements of the arrays whose path matches one of patterns.
+// The empty pattern designates the whole document.
+func IgnoreArrayOrder(patterns ...string) Option {
+	return func(c *comparison) {
+		c.unorderedArrayPaths = append(c.unorderedArrayPaths, c.parsePatterns(patterns)...)
+	}
+}
+
+// NumericTolerance considers numbers equal if they differ by at most tolerance
+func NumericTolerance(tolerance float64) Option {
+	return func(c *comparison) {
+		c.numericTolerance = tolerance
+	}
+}
+
+// AssertJSONEqual checks that got and want are valid JSON and that their contents are semantically equivalent.
+// If they are not, it reports the changes that turn want into got and returns false.
+func AssertJSONEqual(t TestingT, got, want string, options ...Option) bool {
+	t.Helper()
+
+	changes, err := compare(got, want, options...)
+	if err != nil {
+		t.Errorf("%v", err)
+		return false
+	}
+	if len(changes) == 0 {
+		return true
+	}
+
+	lines := make([]string, 0, len(changes))
+	for _, change := range changes {
+		lines = append(lines, change.String())
+	}
+	t.Errorf("JSON contents do not match, changes from want to got:\n%s", strings.Join(lines, "\n"))
+	return false
+}
+
+// compare returns the changes that turn want into got, once options are applied
+func compare(got, want string, options ...Option) ([]sjm.Change, error) {
+	c := &comparison{}
+	for _, option := range options {
+		option(c)
+	}
+	if c.invalidPatternMessage != "" {
+		return nil, fmt.Errorf("%s", c.invalidPatternMessage)
+	}
+
+	normalizedGot, err := c.normalize(got)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse got json: %w", err)
+	}
+	normalizedWant, err := c.normalize(want)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse want json: %w", err)
+	}
+
+	changes, err := sjm.Diff(normalizedWant, normalizedGot)
+	if err != nil {
+		return nil, err
+	}
+
+	relevantChanges := changes[:0]
+	for _, change := range changes {
+		if !c.withinTolerance(change) {
+			relevantChanges = append(relevantChanges, change)
+		}
+	}
+	return relevantChanges, nil
+}
+
+// normalize removes ignored elements and sorts unordered arrays
+func (c *comparison) normalize(document string) (string, error) {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(document), &parsed); err != nil {
+		return "", err
+	}
+	for _, pattern := range c.ignoredPaths {
+		parsed, _ = removeMatching(parsed, pattern)
+	}
+	for _, pattern := range c.unorderedArrayPaths {
+		parsed = transformMatching(parsed, pattern, sortArray)
+	}
+	normalized, err := json.Marshal(parsed)
+	if err != nil {
+		return "", err
+	}
+	return string(normalized), nil
+}
+
+func (c *comparison) withinTolerance(change sjm.Change) bool {
+	if change.Type != sjm.ChangeReplace || c.numericTolerance == 0 {
+		return false
+	}
+	oldNumber, oldIsNumber := change.OldValue.(float64)
+	newNumber, newIsNumber := change.NewValue.(float64)
+	return oldIsNumber && newIsNumber && math.Abs(oldNumber-newNumber) <= c.numericTolerance
+}
+
+var (
+	patternRegexp        = regexp.MustCompile(`^(?:[a-zA-Z0-9_\-]+|\*|\[(?:[0-9]+|\*)\])(?:\.(?:[a-zA-Z0-9_\-]+|\*)|\[(?:[0-9]+|\*)\])*$`)
+	patternSegmentRegexp = regexp.MustCompile(`\[[^\]]+\]|[^.\[]+`)
+)
+
+func (c *comparison) parsePatterns(patterns []string) [][]string {
+	parsedPatterns := make([][]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern == "" {
+			parsedPatterns = append(parsedPatterns, nil)
+			continue
+		}
+		if !patternRegexp.MatchString(pattern) {
+			c.invalidPatternMessage = fmt.Sprintf("cannot parse path pattern [%q], it doesn't seem valid", pattern)
+			continue
+		}
+		parsedPatterns = append(parsedPatterns, patternSegmentRegexp.FindAllString(pattern, -1))
+	}
+	return parsedPatterns
+}
+
+func matchesAttribute(segment, attribute string) bool {
+	return segment == "*" || segment == attribute
+}
+
+func matchesIndex(segment string, index int) bool {
+	return segment == "[*]" || segment == fmt.Sprintf("[%d]", index)
+}
+
+// removeMatching removes the elements of value whose path matches pattern.
+// It returns true if value is an object or an array that only had such elements, to remove it as well.
+func removeMatching(value interface{}, pattern []string) (interface{}, bool) {
+	if len(pattern) == 0 {
+		return nil, false
+	}
+	last := len(pattern) == 1
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if len(value) == 0 {
+			return value, false
+		}
+		for attribute, element := range value {
+			if !matchesAttribute(pattern[0], attribute) {
+				continue
+			}
+			if last {
+				delete(value, attribute)
+				continue
+			}
+			if element, emptied := removeMatching(element, pattern[1:]); emptied {
+				delete(value, attribute)
+			} else {
+				value[attribute] = element
+			}
+		}
+		return value, len(value) == 0
+	case []interface{}:
+		if len(value) == 0 {
+			return value, false
+		}
+		kept := value[:0]
+		for i, element := range value {
+			switch {
+			case !matchesIndex(pattern[0], i):
+				kept = append(kept, element)
+			case !last:
+				if element, emptied := removeMatching(element, pattern[1:]); !emptied {
+					kept = append(kept, element)
+				}
+			}
+		}
+		return kept, len(kept) == 0
+	}
+	return value, false
+}
+
+// transformMatching replaces the elements of value whose path matches pattern by the result of transform
+func transformMatching(value interface{}, pattern []string, transform func(interface{}) interface{}) interface{} {
+	if len(pattern) == 0 {
+		return transform(value)
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for attribute, element := range value {
+			if matchesAttribute(pattern[0], attribute) {
+				value[attribute] = transformMatching(element, pattern[1:], transform)
+			}
+		}
+	case []interface{}:
+		for i, element := range value {
+			if matchesIndex(pattern[0], i) {
+				value[i] = transformMatching(element, pattern[1:], transform)
+			}
+		}
+	}
+	return value
+}
+
+// sortArray sorts the elements of an array by their JSON encoding
+func sortArray(value interface{}) interface{} {
+	array, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+	keys := make(map[int]string, len(array))
+	indices := make([]int, len(array))
+	for i, element := range array {
+		encoded, _ := json.Marshal(element)
+		keys[i] = string(encoded)
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		return keys[indices[a]] < keys[indices[b]]
+	})
+	sorted := make([]interface{}, len(array))
+	for i, index := range indices {
+		sorted[i] = array[index]
+	}
+	return sorted
+}
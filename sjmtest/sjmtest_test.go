@@ -0,0 +1,129 @@
+package sjmtest
+
+import (
+	"fmt"
+	"testing"
+)
+
+type recordingT struct {
+	messages []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func TestAssertJSONEqual(t *testing.T) {
+	tests := map[string]struct {
+		got, want       string
+		options         []Option
+		expectedMessage string
+	}{
+		"equal documents": {
+			got:  `{"name": "Perceval", "titles": ["Knight"]}`,
+			want: `{"titles": ["Knight"], "name": "Perceval"}`,
+		},
+		"different documents": {
+			got:  `{"name": "Perceval", "manager": {"name": "Arthur"}, "titles": ["Knight", "Provençal"]}`,
+			want: `{"name": "Karadoc", "manager": {}, "titles": ["Knight"]}`,
+			expectedMessage: `JSON contents do not match, changes from want to got:
++ manager.name: "Arthur"
+~ name: "Karadoc" -> "Perceval"
++ titles[1]: "Provençal"`,
+		},
+		"invalid got": {
+			got:             `{`,
+			want:            `{}`,
+			expectedMessage: "failed to parse got json: unexpected end of JSON input",
+		},
+		"invalid want": {
+			got:             `{}`,
+			want:            `[`,
+			expectedMessage: "failed to parse want json: unexpected end of JSON input",
+		},
+		"ignored paths": {
+			got:     `{"name": "Perceval", "meta": {"requestId": "a", "at": 1}, "knights": [{"id": 1, "name": "Karadoc"}]}`,
+			want:    `{"name": "Perceval", "meta": {"requestId": "b"}, "knights": [{"id": 2, "name": "Karadoc"}]}`,
+			options: []Option{IgnorePaths("meta.*", "knights[*].id")},
+		},
+		"ignored paths do not hide other differences": {
+			got:     `{"name": "Perceval", "meta": {"requestId": "a"}}`,
+			want:    `{"name": "Karadoc", "meta": {"requestId": "b"}}`,
+			options: []Option{IgnorePaths("meta.requestId")},
+			expectedMessage: `JSON contents do not match, changes from want to got:
+~ name: "Karadoc" -> "Perceval"`,
+		},
+		"objects left empty by ignored paths": {
+			got:     `{"name": "Perceval", "meta": {"requestId": "a"}, "knights": [{"id": 1, "meta": {"at": 1}}]}`,
+			want:    `{"name": "Perceval", "knights": [{"id": 1}]}`,
+			options: []Option{IgnorePaths("meta.*", "knights[*].meta.*")},
+		},
+		"objects already empty are compared": {
+			got:     `{"name": "Perceval", "meta": {}}`,
+			want:    `{"name": "Perceval"}`,
+			options: []Option{IgnorePaths("meta.*")},
+			expectedMessage: `JSON contents do not match, changes from want to got:
++ meta: {}`,
+		},
+		"ignored array element": {
+			got:     `[1, 2, 3]`,
+			want:    `[1, 5, 3]`,
+			options: []Option{IgnorePaths("[1]")},
+		},
+		"invalid ignored path": {
+			got:             `{}`,
+			want:            `{}`,
+			options:         []Option{IgnorePaths("meta..*")},
+			expectedMessage: `cannot parse path pattern ["meta..*"], it doesn't seem valid`,
+		},
+		"ignored array order": {
+			got:     `{"knights": [{"name": "Perceval"}, {"name": "Karadoc"}], "tags": [[2, 1], [4, 3]]}`,
+			want:    `{"knights": [{"name": "Karadoc"}, {"name": "Perceval"}], "tags": [[1, 2], [3, 4]]}`,
+			options: []Option{IgnoreArrayOrder("knights", "tags[*]")},
+		},
+		"ignored array order of the whole document": {
+			got:     `[3, 1, 2]`,
+			want:    `[1, 2, 3]`,
+			options: []Option{IgnoreArrayOrder("")},
+		},
+		"array order matters by default": {
+			got:  `[2, 1]`,
+			want: `[1, 2]`,
+			expectedMessage: `JSON contents do not match, changes from want to got:
+- [0]: 1
++ [1]: 1`,
+		},
+		"numbers within tolerance": {
+			got:     `{"price": 10.001, "quantities": [1, 2.0004]}`,
+			want:    `{"price": 10, "quantities": [1, 2]}`,
+			options: []Option{NumericTolerance(0.01)},
+		},
+		"numbers outside tolerance": {
+			got:     `{"price": 10.1}`,
+			want:    `{"price": 10}`,
+			options: []Option{NumericTolerance(0.01)},
+			expectedMessage: `JSON contents do not match, changes from want to got:
+~ price: 10 -> 10.1`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			recorder := &recordingT{}
+			result := AssertJSONEqual(recorder, test.got, test.want, test.options...)
+
+			if result != (test.expectedMessage == "") {
+				t.Errorf("got result [%v], wanted [%v]", result, test.expectedMessage == "")
+			}
+			var message string
+			if len(recorder.messages) != 0 {
+				message = recorder.messages[0]
+			}
+			if message != test.expectedMessage {
+				t.Errorf("got message [%v], wanted [%v]", message, test.expectedMessage)
+			}
+		})
+	}
+}
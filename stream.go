@@ -0,0 +1,60 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var errNotAStreamedArray = errors.New("streamed document must be a JSON array")
+
+// ModifyArrayStream reads a JSON array from r, applies modifications to each of its elements,
+// and writes the resulting array to w. Paths used by modifications are relative to the element,
+// as with ForEach or Scoped("[*]", ...): they do not include the [*] segment.
+// Elements are decoded, modified and written one at a time, so that memory stays bounded
+// by the largest element rather than by the whole array. Each element is written as soon as it is modified;
+// wrap w in a bufio.Writer to reduce the number of writes.
+func ModifyArrayStream(r io.Reader, w io.Writer, modifications ...JSONModification) error {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("failed to read streamed array: %w", err)
+	}
+	if token != json.Delim('[') {
+		return errNotAStreamedArray
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	var output []byte
+	for i := 0; decoder.More(); i++ {
+		var element json.RawMessage
+		if err := decoder.Decode(&element); err != nil {
+			return fmt.Errorf("failed to read element %d of streamed array: %w", i, err)
+		}
+		modified, err := Modify(string(element), modifications...)
+		if err != nil {
+			return fmt.Errorf("cannot modify element %d of streamed array: %w", i, err)
+		}
+
+		output = output[:0]
+		if i > 0 {
+			output = append(output, ',')
+		}
+		output = append(output, modified...)
+		if _, err := w.Write(output); err != nil {
+			return err
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("failed to read streamed array: %w", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("unexpected data after streamed array")
+	}
+	_, err = io.WriteString(w, "]")
+	return err
+}
@@ -0,0 +1,146 @@
+package slowjsonmutator
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestModifyArrayStream(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		modifications  []JSONModification
+		expectedOutput string
+		expectedError  error
+	}{
+		"modify every element": {
+			input:          `[{"name": "Perceval"}, {"name": "Karadoc", "title": "Knight"}]`,
+			modifications:  []JSONModification{Set("title", "Knight of the Round Table"), Remove("name")},
+			expectedOutput: `[{"title":"Knight of the Round Table"},{"title":"Knight of the Round Table"}]`,
+		},
+		"modifications that need decoding": {
+			input:          "[\n  {\"b\": 1, \"a\": 2},\n  {\"b\": 3}\n]\n",
+			modifications:  []JSONModification{When(Exists("a"), Set("c", true))},
+			expectedOutput: `[{"a":2,"b":1,"c":true},{"b":3}]`,
+		},
+		"empty array": {
+			input:          ` [ ] `,
+			modifications:  []JSONModification{Set("name", "Perceval")},
+			expectedOutput: `[]`,
+		},
+		"not an array": {
+			input:         `{"name": "Perceval"}`,
+			expectedError: errors.New("streamed document must be a JSON array"),
+		},
+		"empty input": {
+			input:         ``,
+			expectedError: errors.New("failed to read streamed array: EOF"),
+		},
+		"truncated array": {
+			input:         `[{"name": "Perceval"}`,
+			expectedError: errors.New("failed to read element 1 of streamed array: unexpected end of JSON input"),
+		},
+		"invalid element": {
+			input:         `[{"name": "Perceval"}, {"name"}]`,
+			expectedError: errors.New("failed to read element 1 of streamed array: invalid character '}' after object key"),
+		},
+		"failing modification": {
+			input:         `[{"name": "Perceval"}, ["Karadoc"]]`,
+			modifications: []JSONModification{Set("name", "Arthur")},
+			expectedError: errors.New("cannot modify element 1 of streamed array: cannot address content of JSON array by attribute"),
+		},
+		"data after the array": {
+			input:         `[1] [2]`,
+			expectedError: errors.New("unexpected data after streamed array"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var output strings.Builder
+			err := ModifyArrayStream(strings.NewReader(test.input), &output, test.modifications...)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if err == nil && output.String() != test.expectedOutput {
+				t.Errorf("unexpected output: wanted [%v], got [%v]", test.expectedOutput, output.String())
+			}
+		})
+	}
+}
+
+// TestModifyArrayStreamWritesElementsImmediately checks that an element is written before the next one is read
+func TestModifyArrayStreamWritesElementsImmediately(t *testing.T) {
+	inputReader, inputWriter := io.Pipe()
+	outputReader, outputWriter := io.Pipe()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- ModifyArrayStream(inputReader, outputWriter, Set("id", 0))
+		outputWriter.Close()
+	}()
+
+	output := bufio.NewReader(outputReader)
+	readOutput := func(expected string) {
+		t.Helper()
+		buffer := make([]byte, len(expected))
+		if _, err := io.ReadFull(output, buffer); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(buffer) != expected {
+			t.Fatalf("unexpected output: wanted [%v], got [%v]", expected, string(buffer))
+		}
+	}
+
+	go io.WriteString(inputWriter, `[{"id": 1}, `)
+	readOutput(`[{"id":0}`)
+	go io.WriteString(inputWriter, `{"id": 2}`)
+	readOutput(`,{"id":0}`)
+	go func() {
+		io.WriteString(inputWriter, `]`)
+		inputWriter.Close()
+	}()
+	readOutput(`]`)
+	if err := <-errs; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestModifyArrayStreamLargeArray(t *testing.T) {
+	const size = 100000
+	reader, writer := io.Pipe()
+	go func() {
+		buffered := bufio.NewWriter(writer)
+		buffered.WriteString("[")
+		for i := 0; i < size; i++ {
+			if i > 0 {
+				buffered.WriteString(",")
+			}
+			buffered.WriteString(`{"id":` + strconv.Itoa(i) + `,"name":"Knight"}`)
+		}
+		buffered.WriteString("]")
+		buffered.Flush()
+		writer.Close()
+	}()
+
+	counter := &elementCounter{}
+	if err := ModifyArrayStream(reader, counter, Remove("name")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if counter.count != size {
+		t.Errorf("unexpected number of elements: wanted [%v], got [%v]", size, counter.count)
+	}
+}
+
+// elementCounter counts the modified elements written to it, without keeping them
+type elementCounter struct {
+	count int
+}
+
+func (c *elementCounter) Write(p []byte) (int, error) {
+	c.count += strings.Count(string(p), `{"id":`)
+	return len(p), nil
+}
@@ -0,0 +1,203 @@
+package slowjsonmutator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Time layouts that are not Go reference layouts
+const (
+	// TimeLayoutAuto detects the layout from the value found at the path
+	TimeLayoutAuto = ""
+	// TimeLayoutUnixSeconds represents a time as a JSON number of seconds since the Unix epoch
+	TimeLayoutUnixSeconds = "unix"
+	// TimeLayoutUnixMilliseconds represents a time as a JSON number of milliseconds since the Unix epoch
+	TimeLayoutUnixMilliseconds = "unixmilli"
+)
+
+// fractional variants of the Unix layouts, which parseTime returns for timestamps that have a fractional part
+const (
+	timeLayoutFractionalUnixSeconds      = TimeLayoutUnixSeconds + fractionalUnitSuffix
+	timeLayoutFractionalUnixMilliseconds = TimeLayoutUnixMilliseconds + fractionalUnitSuffix
+	fractionalUnitSuffix                 = ".fraction"
+)
+
+// now returns the current time; it is a variable so that tests can freeze it
+var now = time.Now
+
+// detectableTimeLayouts are the layouts tried, in order, when detecting the layout of a JSON string
+var detectableTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// unixMillisecondsThreshold is the absolute value above which an epoch timestamp is considered to be in milliseconds.
+// In seconds, it would be past the year 5000; in milliseconds, it is in 1973.
+const unixMillisecondsThreshold = 1e11
+
+var fractionalSecondsRegexp = regexp.MustCompile(`:[0-9]{2}[.,]([0-9]+)`)
+
+// ShiftTime shifts the time at the given path by duration, keeping its original format.
+// The format is detected: JSON numbers are Unix timestamps (in seconds or milliseconds),
+// and JSON strings are RFC 3339 or one of a few common layouts.
+func ShiftTime(path string, duration time.Duration) JSONModification {
+	return ShiftTimeWithLayout(path, duration, TimeLayoutAuto)
+}
+
+// ShiftTimeWithLayout shifts the time at the given path by duration, reading and writing it with layout.
+// layout is either a Go reference layout, TimeLayoutUnixSeconds, TimeLayoutUnixMilliseconds or TimeLayoutAuto.
+func ShiftTimeWithLayout(path string, duration time.Duration, layout string) JSONModification {
+	return func(toModify interface{}) (interface{}, error) {
+		pathSegments, err := parseJSONPath(path)
+		if err != nil {
+			return nil, err
+		}
+		current, ok := get(toModify, pathSegments)
+		if !ok || current == nil {
+			return nil, fmt.Errorf("cannot shift time at path [%q]: no value found", path)
+		}
+		parsed, layout, err := parseTime(current, layout)
+		if err != nil {
+			return nil, fmt.Errorf("cannot shift time at path [%q]: %w", path, err)
+		}
+		return set(toModify, pathSegments, formatTime(parsed.Add(duration), layout))
+	}
+}
+
+// SetTimeRelative sets the element at the given path to the current time shifted by offset, in UTC.
+// layout is either a Go reference layout, TimeLayoutUnixSeconds, TimeLayoutUnixMilliseconds or TimeLayoutAuto,
+// in which case the layout of the value already at the path is kept (RFC 3339 is used if there is none).
+func SetTimeRelative(path string, offset time.Duration, layout string) JSONModification {
+	return func(toModify interface{}) (interface{}, error) {
+		pathSegments, err := parseJSONPath(path)
+		if err != nil {
+			return nil, err
+		}
+		if layout == TimeLayoutAuto {
+			layout = time.RFC3339
+			if current, ok := get(toModify, pathSegments); ok && current != nil {
+				if _, layout, err = parseTime(current, TimeLayoutAuto); err != nil {
+					return nil, fmt.Errorf("cannot set time at path [%q]: %w", path, err)
+				}
+			}
+		}
+		return set(toModify, pathSegments, formatTime(now().UTC().Add(offset), layout))
+	}
+}
+
+// parseTime parses value with layout, detecting the layout if it is TimeLayoutAuto.
+// It returns the layout that must be used to format the time back in the same format.
+func parseTime(value interface{}, layout string) (time.Time, string, error) {
+	if number, ok := toFloat64(value); ok {
+		if layout == TimeLayoutAuto {
+			layout = TimeLayoutUnixMilliseconds
+			if math.Abs(number) < unixMillisecondsThreshold {
+				layout = TimeLayoutUnixSeconds
+			}
+		}
+		switch layout {
+		case TimeLayoutUnixSeconds:
+			return unixSecondsToTime(number), withFractionalUnit(layout, number), nil
+		case TimeLayoutUnixMilliseconds:
+			return unixMillisecondsToTime(number), withFractionalUnit(layout, number), nil
+		default:
+			return time.Time{}, "", fmt.Errorf("expected a string for layout [%q], found a number", layout)
+		}
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return time.Time{}, "", errors.New("value is neither a string nor a number")
+	}
+	switch layout {
+	case TimeLayoutAuto:
+		for _, candidate := range detectableTimeLayouts {
+			if parsed, err := time.Parse(candidate, str); err == nil {
+				return parsed, withFractionalSeconds(candidate, str), nil
+			}
+		}
+		return time.Time{}, "", fmt.Errorf("cannot detect time layout of [%q]", str)
+	case TimeLayoutUnixSeconds, TimeLayoutUnixMilliseconds:
+		return time.Time{}, "", fmt.Errorf("expected a number for layout [%q], found a string", layout)
+	default:
+		parsed, err := time.Parse(layout, str)
+		if err != nil {
+			return time.Time{}, "", err
+		}
+		return parsed, layout, nil
+	}
+}
+
+// withFractionalSeconds adds to layout as many fractional second digits as there are in value,
+// since time.Parse accepts them even when the layout does not mention them
+func withFractionalSeconds(layout, value string) string {
+	match := fractionalSecondsRegexp.FindStringSubmatch(value)
+	if match == nil || strings.Contains(layout, "05.") {
+		return layout
+	}
+	return strings.Replace(layout, "05", "05."+strings.Repeat("0", len(match[1])), 1)
+}
+
+// withFractionalUnit returns the fractional variant of a Unix layout if number has a fractional part
+func withFractionalUnit(layout string, number float64) string {
+	if number == math.Trunc(number) {
+		return layout
+	}
+	return layout + fractionalUnitSuffix
+}
+
+func formatTime(t time.Time, layout string) interface{} {
+	switch layout {
+	case TimeLayoutUnixSeconds:
+		return float64(t.Unix())
+	case TimeLayoutUnixMilliseconds:
+		return float64(t.UnixNano() / int64(time.Millisecond))
+	case timeLayoutFractionalUnixSeconds:
+		return float64(t.Unix()) + float64(t.Nanosecond())/float64(time.Second)
+	case timeLayoutFractionalUnixMilliseconds:
+		return float64(t.Unix())*1000 + float64(t.Nanosecond())/float64(time.Millisecond)
+	default:
+		return t.Format(layout)
+	}
+}
+
+func unixSecondsToTime(seconds float64) time.Time {
+	integer, fractional := math.Modf(seconds)
+	return time.Unix(int64(integer), int64(fractional*float64(time.Second))).UTC()
+}
+
+func unixMillisecondsToTime(milliseconds float64) time.Time {
+	integer, fractional := math.Modf(milliseconds)
+	nanoseconds := int64(integer)%1000*int64(time.Millisecond) + int64(fractional*float64(time.Millisecond))
+	return time.Unix(int64(integer)/1000, nanoseconds).UTC()
+}
+
+// toFloat64 converts JSON numbers, as decoded by encoding/json or set by users, to float64
+func toFloat64(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, true
+	case float32:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case int32:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case json.Number:
+		number, err := value.Float64()
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
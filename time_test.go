@@ -0,0 +1,156 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeModifications(t *testing.T) {
+	frozenNow := time.Date(2021, time.June, 15, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return frozenNow }
+	defer func() { now = time.Now }()
+
+	tests := map[string]struct {
+		input          string
+		modifications  []JSONModification
+		expectedOutput string
+		expectedError  error
+	}{
+		"shift an RFC 3339 time": {
+			input: `{"createdAt": "2021-01-01T10:00:00Z"}`,
+			modifications: []JSONModification{
+				ShiftTime("createdAt", 36*time.Hour),
+			},
+			expectedOutput: `{"createdAt": "2021-01-02T22:00:00Z"}`,
+		},
+		"shift an RFC 3339 time keeps offset and fractional seconds": {
+			input: `{"createdAt": "2021-01-01T10:00:00.120+02:00"}`,
+			modifications: []JSONModification{
+				ShiftTime("createdAt", -time.Hour),
+			},
+			expectedOutput: `{"createdAt": "2021-01-01T09:00:00.120+02:00"}`,
+		},
+		"shift a date": {
+			input: `{"birthday": "2021-02-28"}`,
+			modifications: []JSONModification{
+				ShiftTime("birthday", 24*time.Hour),
+			},
+			expectedOutput: `{"birthday": "2021-03-01"}`,
+		},
+		"shift an epoch in seconds": {
+			input: `{"quests": [{"startedAt": 1600000000}]}`,
+			modifications: []JSONModification{
+				ShiftTime("quests[0].startedAt", time.Minute),
+			},
+			expectedOutput: `{"quests": [{"startedAt": 1600000060}]}`,
+		},
+		"shift an epoch in milliseconds": {
+			input: `{"startedAt": 1600000000000}`,
+			modifications: []JSONModification{
+				ShiftTime("startedAt", time.Second),
+			},
+			expectedOutput: `{"startedAt": 1600000001000}`,
+		},
+		"shift an epoch in seconds keeps the fraction": {
+			input: `{"expiresAt": 1609495200.5}`,
+			modifications: []JSONModification{
+				ShiftTime("expiresAt", time.Hour),
+			},
+			expectedOutput: `{"expiresAt": 1609498800.5}`,
+		},
+		"shift an epoch in milliseconds keeps the fraction": {
+			input: `{"expiresAt": 1609495200000.25}`,
+			modifications: []JSONModification{
+				ShiftTimeWithLayout("expiresAt", -time.Second, TimeLayoutUnixMilliseconds),
+			},
+			expectedOutput: `{"expiresAt": 1609495199000.25}`,
+		},
+		"shift a negative epoch in milliseconds": {
+			input: `{"expiresAt": -1500}`,
+			modifications: []JSONModification{
+				ShiftTimeWithLayout("expiresAt", time.Second, TimeLayoutUnixMilliseconds),
+			},
+			expectedOutput: `{"expiresAt": -500}`,
+		},
+		"shift a time with a custom layout": {
+			input: `{"startedAt": "15/06/2021 12h00"}`,
+			modifications: []JSONModification{
+				ShiftTimeWithLayout("startedAt", 90*time.Minute, "02/01/2006 15h04"),
+			},
+			expectedOutput: `{"startedAt": "15/06/2021 13h30"}`,
+		},
+		"shift a missing time": {
+			input: `{}`,
+			modifications: []JSONModification{
+				ShiftTime("startedAt", time.Minute),
+			},
+			expectedError: errors.New(`cannot shift time at path ["startedAt"]: no value found`),
+		},
+		"shift a time with an unknown layout": {
+			input: `{"startedAt": "yesterday"}`,
+			modifications: []JSONModification{
+				ShiftTime("startedAt", time.Minute),
+			},
+			expectedError: errors.New(`cannot shift time at path ["startedAt"]: cannot detect time layout of ["yesterday"]`),
+		},
+		"shift a value that is not a time": {
+			input: `{"startedAt": true}`,
+			modifications: []JSONModification{
+				ShiftTime("startedAt", time.Minute),
+			},
+			expectedError: errors.New(`cannot shift time at path ["startedAt"]: value is neither a string nor a number`),
+		},
+		"set a relative time with default layout": {
+			input: `{}`,
+			modifications: []JSONModification{
+				SetTimeRelative("expiresAt", time.Hour, TimeLayoutAuto),
+			},
+			expectedOutput: `{"expiresAt": "2021-06-15T13:00:00Z"}`,
+		},
+		"set a relative time keeping the existing layout": {
+			input: `{"expiresAt": 1}`,
+			modifications: []JSONModification{
+				SetTimeRelative("expiresAt", -time.Hour, TimeLayoutAuto),
+			},
+			expectedOutput: `{"expiresAt": 1623754800}`,
+		},
+		"set a relative time in milliseconds": {
+			input: `{}`,
+			modifications: []JSONModification{
+				SetTimeRelative("expiresAt", 0, TimeLayoutUnixMilliseconds),
+			},
+			expectedOutput: `{"expiresAt": 1623758400000}`,
+		},
+		"set a relative time with a custom layout": {
+			input: `{}`,
+			modifications: []JSONModification{
+				SetTimeRelative("expiresAt", 24*time.Hour, "2006-01-02"),
+			},
+			expectedOutput: `{"expiresAt": "2021-06-16"}`,
+		},
+		"set a relative time over a value that is not a time": {
+			input: `{"expiresAt": "never"}`,
+			modifications: []JSONModification{
+				SetTimeRelative("expiresAt", 0, TimeLayoutAuto),
+			},
+			expectedError: errors.New(`cannot set time at path ["expiresAt"]: cannot detect time layout of ["never"]`),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := Modify(test.input, test.modifications...)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if output == test.expectedOutput {
+				return
+			}
+			if message, ok := JSONEqual(output, test.expectedOutput); !ok {
+				t.Error("unexpected output: " + message)
+			}
+		})
+	}
+}
@@ -0,0 +1,435 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlIndent is the number of spaces used to indent YAML output
+const yamlIndent = 2
+
+// ModifyYAML applies modifications to a YAML string, using the same paths as with JSON.
+// Each document of the input is converted to JSON-compatible data, modified, and converted back to YAML.
+// Comments, key order and scalar styles are kept for what the modifications left untouched;
+// new attributes are appended, sorted by name, to the objects that receive them.
+func ModifyYAML(input string, modifications ...JSONModification) (string, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(input))
+	var output strings.Builder
+	encoder := yaml.NewEncoder(&output)
+	encoder.SetIndent(yamlIndent)
+
+	encoded := false
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return "", err
+		}
+
+		var root *yaml.Node
+		if len(document.Content) != 0 {
+			root = document.Content[0]
+		}
+		reconciler := &yamlReconciler{values: make(map[*yaml.Node]interface{}), replaced: make(map[*yaml.Node]bool)}
+		value, err := reconciler.value(root)
+		if err != nil {
+			return "", err
+		}
+		modified, err := Apply(value, modifications...)
+		if err != nil {
+			return "", err
+		}
+		reconciled, err := reconciler.reconcile(root, modified)
+		if err != nil {
+			return "", err
+		}
+		document.Content = []*yaml.Node{reconciled}
+		if err := encoder.Encode(&document); err != nil {
+			return "", err
+		}
+		encoded = true
+	}
+
+	if !encoded {
+		return "", nil
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
+
+// fromYAMLNode converts node to untyped JSON data
+func fromYAMLNode(node *yaml.Node) (interface{}, error) {
+	if node == nil {
+		return nil, nil
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	return fromYAMLValue(value)
+}
+
+// fromYAMLValue converts what yaml.v3 decodes into an interface{} to untyped JSON data.
+// Keys that are not strings are formatted, and timestamps are written in RFC 3339 format.
+func fromYAMLValue(value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for attribute, element := range value {
+			convertedElement, err := fromYAMLValue(element)
+			if err != nil {
+				return nil, err
+			}
+			converted[attribute] = convertedElement
+		}
+		return converted, nil
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for attribute, element := range value {
+			convertedElement, err := fromYAMLValue(element)
+			if err != nil {
+				return nil, err
+			}
+			converted[fmt.Sprint(attribute)] = convertedElement
+		}
+		return converted, nil
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, element := range value {
+			convertedElement, err := fromYAMLValue(element)
+			if err != nil {
+				return nil, err
+			}
+			converted[i] = convertedElement
+		}
+		return converted, nil
+	case time.Time:
+		return value.Format(time.RFC3339Nano), nil
+	default:
+		return normalizeValue(value)
+	}
+}
+
+// yamlReconciler reconciles the nodes of a YAML document with modified data
+type yamlReconciler struct {
+	// values are the JSON data of the nodes of the document, which the elements of modified sequences are matched with
+	values map[*yaml.Node]interface{}
+	// replaced are the anchored nodes that no longer hold their original value, which aliases cannot refer to anymore
+	replaced map[*yaml.Node]bool
+}
+
+// value converts node to untyped JSON data, as fromYAMLNode does, and keeps the data of each of its nodes
+func (r *yamlReconciler) value(node *yaml.Node) (interface{}, error) {
+	if node == nil {
+		return nil, nil
+	}
+	if value, ok := r.values[node]; ok {
+		return value, nil
+	}
+
+	var value interface{}
+	var err error
+	switch node.Kind {
+	case yaml.AliasNode:
+		value, err = r.value(node.Alias)
+	case yaml.SequenceNode:
+		elements := make([]interface{}, len(node.Content))
+		for i, element := range node.Content {
+			if elements[i], err = r.value(element); err != nil {
+				return nil, err
+			}
+		}
+		value = elements
+	case yaml.MappingNode:
+		value, err = r.mappingValue(node)
+	default:
+		value, err = fromYAMLNode(node)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.values[node] = value
+	return value, nil
+}
+
+// mappingValue converts a mapping to an object: explicit members take precedence over merged ones,
+// and the first merged mapping over the next ones. Mappings that yaml.v3 rejects, for instance because
+// of duplicate keys, are left to fromYAMLNode, which reports why.
+func (r *yamlReconciler) mappingValue(node *yaml.Node) (interface{}, error) {
+	object := make(map[string]interface{}, len(node.Content)/2)
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].ShortTag() == yamlMergeTag {
+			merges = append(merges, node.Content[i+1])
+			continue
+		}
+		key, err := yamlKey(node.Content[i])
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := object[key]; ok {
+			return fromYAMLNode(node)
+		}
+		if object[key], err = r.value(node.Content[i+1]); err != nil {
+			return nil, err
+		}
+	}
+
+	sources := mergeSources(merges)
+	for _, source := range sources {
+		value, err := r.value(source)
+		if err != nil {
+			return nil, err
+		}
+		members, ok := value.(map[string]interface{})
+		if !ok {
+			return fromYAMLNode(node)
+		}
+		for key, element := range members {
+			if _, ok := object[key]; !ok {
+				object[key] = element
+			}
+		}
+	}
+	return object, nil
+}
+
+// reconcile returns a node holding value, keeping the comments, styles and aliases of node where possible
+func (r *yamlReconciler) reconcile(node *yaml.Node, value interface{}) (*yaml.Node, error) {
+	reconciled, err := r.reconcileNode(node, value)
+	if err == nil && node != nil && node.Anchor != "" && reconciled != node {
+		r.replaced[node] = true
+	}
+	return reconciled, err
+}
+
+func (r *yamlReconciler) reconcileNode(node *yaml.Node, value interface{}) (*yaml.Node, error) {
+	if node == nil {
+		return newYAMLNode(nil, value)
+	}
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		if !r.replaced[node.Alias] {
+			target, err := r.reconcileNode(node.Alias, value)
+			if err != nil {
+				return nil, err
+			}
+			if target == node.Alias {
+				return node, nil
+			}
+		}
+		fresh, err := newYAMLNode(node, value)
+		if err == nil && fresh.Kind == node.Alias.Kind && (fresh.Kind != yaml.ScalarNode || fresh.Tag == node.Alias.Tag) {
+			fresh.Style = node.Alias.Style
+		}
+		return fresh, err
+	case yaml.MappingNode:
+		if value, ok := value.(map[string]interface{}); ok {
+			return r.reconcileMapping(node, value)
+		}
+	case yaml.SequenceNode:
+		if value, ok := value.([]interface{}); ok {
+			originals := make([]interface{}, len(node.Content))
+			for i, element := range node.Content {
+				originals[i] = r.values[element]
+			}
+			matches := matchElements(originals, value)
+			unchanged := elementsUnchanged(matches, len(node.Content))
+			content := make([]*yaml.Node, 0, len(value))
+			for i, element := range value {
+				var elementNode *yaml.Node
+				if matches[i] >= 0 {
+					elementNode = node.Content[matches[i]]
+				}
+				reconciledElement, err := r.reconcile(elementNode, element)
+				if err != nil {
+					return nil, err
+				}
+				unchanged = unchanged && reconciledElement == elementNode
+				content = append(content, reconciledElement)
+			}
+			return reconciledYAMLContainer(node, content, unchanged), nil
+		}
+	case yaml.ScalarNode:
+		if original, err := fromYAMLNode(node); err == nil && sameScalar(original, value) {
+			return node, nil
+		}
+	}
+	return newYAMLNode(node, value)
+}
+
+// reconcileMapping keeps the members of node that are still in value, in their original order, and appends the new ones.
+// Merge keys are kept as long as the members they merge are still in value; members whose value changed are then
+// written explicitly, overriding the merged ones.
+func (r *yamlReconciler) reconcileMapping(node *yaml.Node, value map[string]interface{}) (*yaml.Node, error) {
+	keys := make([]string, 0, len(node.Content)/2)
+	var merges []*yaml.Node
+	isMerge := make([]bool, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if isMerge[i/2] = node.Content[i].ShortTag() == yamlMergeTag; isMerge[i/2] {
+			// yaml.v3 would write the tag of merge keys, which << implies; the document was decoded for this call only
+			node.Content[i].Tag = ""
+			merges = append(merges, node.Content[i+1])
+			continue
+		}
+		key, err := yamlKey(node.Content[i])
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	states, added := planMembers(keys, value)
+	unchanged := membersUnchanged(states, added)
+
+	mergesKept := len(merges) != 0
+	if mergesKept {
+		written := make(map[string]bool, len(keys))
+		for i, key := range keys {
+			written[key] = states[i] != memberRemoved
+		}
+		merged, ok := r.mergedMembers(merges)
+		for key, element := range merged {
+			if written[key] {
+				continue
+			}
+			if modified, found := value[key]; !found {
+				ok = false
+			} else if reflect.DeepEqual(element, modified) {
+				written[key] = true
+			}
+		}
+		if mergesKept = ok; mergesKept {
+			remaining := added[:0]
+			for _, attribute := range added {
+				if !written[attribute] {
+					remaining = append(remaining, attribute)
+				}
+			}
+			added = remaining
+			unchanged = membersUnchanged(states, added)
+		}
+	}
+
+	content := make([]*yaml.Node, 0, 2*len(value))
+	member := 0
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, elementNode := node.Content[i], node.Content[i+1]
+		if isMerge[i/2] {
+			if mergesKept {
+				content = append(content, keyNode, elementNode)
+			}
+			unchanged = unchanged && mergesKept
+			continue
+		}
+		switch states[member] {
+		case memberShadowed:
+			content = append(content, keyNode, elementNode)
+		case memberHeld:
+			reconciledElement, err := r.reconcile(elementNode, value[keys[member]])
+			if err != nil {
+				return nil, err
+			}
+			unchanged = unchanged && reconciledElement == elementNode
+			content = append(content, keyNode, reconciledElement)
+		}
+		member++
+	}
+	for _, attribute := range added {
+		elementNode, err := newYAMLNode(nil, value[attribute])
+		if err != nil {
+			return nil, err
+		}
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: attribute}
+		content = append(content, keyNode, elementNode)
+	}
+	return reconciledYAMLContainer(node, content, unchanged), nil
+}
+
+// mergedMembers returns the members that merge keys bring into a mapping, the first merged mapping taking precedence,
+// and whether the aliases they use still refer to anchors holding their original value
+func (r *yamlReconciler) mergedMembers(merges []*yaml.Node) (map[string]interface{}, bool) {
+	sources := mergeSources(merges)
+	merged := make(map[string]interface{})
+	for i := len(sources) - 1; i >= 0; i-- {
+		if sources[i].Kind == yaml.AliasNode && r.replaced[sources[i].Alias] {
+			return nil, false
+		}
+		value, err := r.value(sources[i])
+		if err != nil {
+			return nil, false
+		}
+		members, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		for key, element := range members {
+			merged[key] = element
+		}
+	}
+	return merged, true
+}
+
+func reconciledYAMLContainer(node *yaml.Node, content []*yaml.Node, unchanged bool) *yaml.Node {
+	if unchanged {
+		return node
+	}
+	reconciled := *node
+	reconciled.Content = content
+	return &reconciled
+}
+
+// mergeSources returns the mappings that merge keys refer to, in order of precedence
+func mergeSources(merges []*yaml.Node) []*yaml.Node {
+	var sources []*yaml.Node
+	for _, merge := range merges {
+		if merge.Kind == yaml.SequenceNode {
+			sources = append(sources, merge.Content...)
+		} else {
+			sources = append(sources, merge)
+		}
+	}
+	return sources
+}
+
+// yamlMergeTag is the tag of the << keys that merge mappings into the mapping holding them
+const yamlMergeTag = "!!merge"
+
+// yamlKey returns the attribute that a mapping key becomes in JSON data, as fromYAMLValue formats it
+func yamlKey(keyNode *yaml.Node) (string, error) {
+	if keyNode.Kind == yaml.ScalarNode && keyNode.ShortTag() == "!!str" {
+		return keyNode.Value, nil
+	}
+	var key interface{}
+	if err := keyNode.Decode(&key); err != nil {
+		return "", err
+	}
+	if key, ok := key.(string); ok {
+		return key, nil
+	}
+	return fmt.Sprint(key), nil
+}
+
+// newYAMLNode returns a node holding value, with the comments of the node it replaces, and its style if it is a scalar of the same type
+func newYAMLNode(replaced *yaml.Node, value interface{}) (*yaml.Node, error) {
+	fresh := &yaml.Node{}
+	if err := fresh.Encode(value); err != nil {
+		return nil, err
+	}
+	if replaced != nil {
+		fresh.HeadComment, fresh.LineComment, fresh.FootComment = replaced.HeadComment, replaced.LineComment, replaced.FootComment
+		if fresh.Kind == yaml.ScalarNode && replaced.Kind == yaml.ScalarNode && fresh.Tag == replaced.Tag {
+			fresh.Style = replaced.Style
+		}
+	}
+	return fresh, nil
+}
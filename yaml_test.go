@@ -0,0 +1,196 @@
+package slowjsonmutator
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestModifyYAML(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		modifications  []JSONModification
+		expectedOutput string
+		expectedError  error
+	}{
+		"comments, order and styles are kept": {
+			input: `# knight of the Round Table
+name: "Perceval" # aka Provençal le Gaulois
+questsAchieved: 0
+manager:
+  name: Arthur
+  titles: [King]
+`,
+			modifications: []JSONModification{Set("name", "Karadoc"), Set("manager.titles[1]", "Suzerain"), Remove("questsAchieved")},
+			expectedOutput: `# knight of the Round Table
+name: "Karadoc" # aka Provençal le Gaulois
+manager:
+  name: Arthur
+  titles: [King, Suzerain]
+`,
+		},
+		"new attributes are appended": {
+			input: `kind: Deployment
+metadata:
+  name: camelot
+`,
+			modifications: []JSONModification{Set("spec.replicas", 3), Set("metadata.labels.app", "camelot"), Set("apiVersion", "apps/v1")},
+			expectedOutput: `kind: Deployment
+metadata:
+  name: camelot
+  labels:
+    app: camelot
+apiVersion: apps/v1
+spec:
+  replicas: 3
+`,
+		},
+		"several documents": {
+			input: `name: Perceval
+---
+name: Karadoc
+`,
+			modifications: []JSONModification{Set("title", "Knight")},
+			expectedOutput: `name: Perceval
+title: Knight
+---
+name: Karadoc
+title: Knight
+`,
+		},
+		"keys that are not strings and timestamps": {
+			input: `1: one
+true: yes
+createdAt: 2021-01-01T10:00:00Z
+`,
+			modifications: []JSONModification{When(Equals("1", "one"), Set("createdAt", "2021-01-02T10:00:00Z")), ShiftTime("createdAt", 0)},
+			expectedOutput: `1: one
+true: yes
+createdAt: "2021-01-02T10:00:00Z"
+`,
+		},
+		"merge keys are kept": {
+			input: `base: &b {x: 1, z: 0}
+child: {<<: *b, y: 2}
+`,
+			modifications: []JSONModification{Set("child.y", 3)},
+			expectedOutput: `base: &b {x: 1, z: 0}
+child: {<<: *b, y: 3}
+`,
+		},
+		"merge keys of anchored mappings are kept": {
+			input: `base: &b {x: 1}
+dev: &dev {<<: *b, y: 2}
+prod: {<<: *dev, z: 3}
+`,
+			modifications: []JSONModification{Set("prod.z", 4)},
+			expectedOutput: `base: &b {x: 1}
+dev: &dev {<<: *b, y: 2}
+prod: {<<: *dev, z: 4}
+`,
+		},
+		"merged members are overridden": {
+			input: `base: &b {x: 1, z: 0}
+child: {<<: [*b, {w: 4}], y: 2}
+`,
+			modifications: []JSONModification{Set("child.x", 3)},
+			expectedOutput: `base: &b {x: 1, z: 0}
+child: {<<: [*b, {w: 4}], y: 2, x: 3}
+`,
+		},
+		"merge keys are replaced when merged members are removed": {
+			input: `base: &b {x: 1, z: 0}
+child: {<<: *b, y: 2}
+`,
+			modifications: []JSONModification{Remove("child.z")},
+			expectedOutput: `base: &b {x: 1, z: 0}
+child: {y: 2, x: 1}
+`,
+		},
+		"aliases of modified anchors are replaced": {
+			input: `base: &b {x: 1}
+same: *b
+child: {<<: *b}
+`,
+			modifications: []JSONModification{Set("base.x", 2)},
+			expectedOutput: `base: &b {x: 2}
+same: {x: 1}
+child: {x: 1}
+`,
+		},
+		"comments stay with the items that remain after a removal": {
+			input: `items:
+  # first
+  - a # one
+  # second
+  - b # two
+  - c
+`,
+			modifications: []JSONModification{Remove("items[0]")},
+			expectedOutput: `items:
+  # second
+  - b # two
+  - c
+`,
+		},
+		"failing modification": {
+			input: `titles: [Knight]
+`,
+			modifications: []JSONModification{Set("", nil)},
+			expectedError: errors.New(`cannot parse json path [""], it doesn't seem valid`),
+		},
+		"empty input": {
+			input:          ``,
+			modifications:  []JSONModification{Set("name", "Perceval")},
+			expectedOutput: ``,
+		},
+		"invalid yaml": {
+			input:         "name: [Perceval\n",
+			expectedError: errors.New("yaml: line 1: did not find expected ',' or ']'"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, err := ModifyYAML(test.input, test.modifications...)
+			if !ErrorEqual(err, test.expectedError) {
+				t.Errorf("unexpected error: wanted [%v], got [%v]", test.expectedError, err)
+				return
+			}
+			if output != test.expectedOutput {
+				t.Errorf("unexpected output: wanted [%v], got [%v]", test.expectedOutput, output)
+			}
+		})
+	}
+}
+
+func TestYAMLReconcilerValue(t *testing.T) {
+	tests := map[string]string{
+		"scalars and timestamps":      "name: Perceval\nquests: 0\nborn: 2021-01-01T10:00:00Z\nretired: false\nmanager: ~\n",
+		"keys that are not strings":   "1: one\ntrue: yes\n1.5: half\n",
+		"aliases":                     "base: &b [1, {x: 2}]\ncopy: *b\n",
+		"merge keys":                  "a: &a {x: 1, y: 1}\nb: &b {y: 2, z: 2}\nc: {<<: [*a, *b], x: 3}\nd: {x: 4, <<: *a}\n",
+		"duplicate keys":              "a: 1\na: 2\n",
+		"merge of a scalar":           "a: &a 1\nb: {<<: *a}\n",
+		"nested sequences of aliases": "k: &k {x: 1}\nl: [[*k, *k], [{<<: *k}]]\n",
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			var document yaml.Node
+			if err := yaml.Unmarshal([]byte(input), &document); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected, expectedErr := fromYAMLNode(document.Content[0])
+			reconciler := &yamlReconciler{values: make(map[*yaml.Node]interface{}), replaced: make(map[*yaml.Node]bool)}
+			value, err := reconciler.value(document.Content[0])
+			if !ErrorEqual(err, expectedErr) {
+				t.Fatalf("unexpected error: wanted [%v], got [%v]", expectedErr, err)
+			}
+			if diff := DeepEqual(value, expected); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}